@@ -5,25 +5,46 @@ import "time"
 type ChannelIterator struct {
 	input   <-chan interface{}
 	timeout time.Duration
+	clock   Clock
 }
 
 func NewChannelIterator(input <-chan interface{}, timeout time.Duration) (res *ChannelIterator) {
 	res = &ChannelIterator{
 		input:   input,
 		timeout: timeout,
+		clock:   SystemClock{},
 	}
 	return
 }
 
+// WithClock sets the clock used for the timeout.
+func (ci *ChannelIterator) WithClock(clock Clock) *ChannelIterator {
+	ci.clock = clock
+	return ci
+}
+
 func (ci *ChannelIterator) Next() (interface{}, bool) {
 	if ci.timeout > 0 {
+		// a ready value is taken without starting the timeout
+		select {
+		case v, ok := <-ci.input:
+			if !ok {
+				return nil, false
+			}
+			return v, true
+		default:
+		}
+
+		timer := ci.clock.NewTimer(ci.timeout)
+		defer timer.Stop()
+
 		select {
 		case v, ok := <-ci.input:
 			if !ok {
 				return nil, false
 			}
 			return v, true
-		case <-time.After(ci.timeout):
+		case <-timer.C():
 			return nil, false
 		}
 	}
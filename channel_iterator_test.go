@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/dc0d/streamer"
+	"github.com/dc0d/streamer/streamertest"
 
 	assert "github.com/stretchr/testify/require"
 )
@@ -58,8 +59,14 @@ func Test_channel_iterator_timeout(t *testing.T) {
 			ch := make(chan interface{}, 1)
 			ch <- 1
 
+			clock := streamertest.NewFakeClock()
+			go func() {
+				clock.BlockUntil(1)
+				clock.Advance(time.Millisecond * 50)
+			}()
+
 			input = ch
-			iterator = streamer.NewChannelIterator(input, time.Millisecond*50)
+			iterator = streamer.NewChannelIterator(input, time.Millisecond*50).WithClock(clock)
 		}
 
 		index := 0
@@ -89,7 +96,7 @@ func Test_channel_iterator_timeout(t *testing.T) {
 			}()
 
 			input = ch
-			iterator = streamer.NewChannelIterator(input, time.Millisecond*50)
+			iterator = streamer.NewChannelIterator(input, time.Millisecond*50).WithClock(streamertest.NewFakeClock())
 		}
 
 		index := 0
@@ -0,0 +1,51 @@
+package streamer
+
+import "time"
+
+// Clock is the source of time for time-based stages, so they can be tested
+// with a fake clock. Stages that may stop waiting early use NewTimer, so the
+// timer can be released.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer of a Clock, like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	// Stop prevents the timer from firing; it returns false if the timer
+	// already fired or was stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock of the time package; it is the default.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (SystemClock) NewTimer(d time.Duration) Timer { return systemTimer{timer: time.NewTimer(d)} }
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (st systemTimer) C() <-chan time.Time { return st.timer.C }
+
+func (st systemTimer) Stop() bool { return st.timer.Stop() }
+
+// WithClock sets the clock of the time-based stages added after it.
+func (st *Stream) WithClock(clock Clock) *Stream {
+	res := *st
+	res.clock = clock
+	return &res
+}
+
+func (st *Stream) clockOrDefault() Clock {
+	if st.clock == nil {
+		return SystemClock{}
+	}
+	return st.clock
+}
@@ -0,0 +1,88 @@
+package streamer
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// Codec serializes stream items, e.g. when they are spilled to disk.
+type Codec interface {
+	NewEncoder(w io.Writer) ItemEncoder
+	NewDecoder(r io.Reader) ItemDecoder
+}
+
+type ItemEncoder interface {
+	Encode(item interface{}) error
+}
+
+// ItemDecoder returns io.EOF when there are no more items.
+type ItemDecoder interface {
+	Decode() (interface{}, error)
+}
+
+//
+
+// GobCodec encodes items using encoding/gob. Concrete types other than the
+// basic ones must be registered using gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) NewEncoder(w io.Writer) ItemEncoder { return gobItemEncoder{enc: gob.NewEncoder(w)} }
+
+func (GobCodec) NewDecoder(r io.Reader) ItemDecoder { return gobItemDecoder{dec: gob.NewDecoder(r)} }
+
+type gobItemEncoder struct{ enc *gob.Encoder }
+
+func (ge gobItemEncoder) Encode(item interface{}) error { return ge.enc.Encode(&item) }
+
+type gobItemDecoder struct{ dec *gob.Decoder }
+
+func (gd gobItemDecoder) Decode() (interface{}, error) {
+	var item interface{}
+	if err := gd.dec.Decode(&item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+//
+
+// JSONCodec encodes items using encoding/json. If New is set, it must return
+// a pointer to a new value of the target type, and decoded items are the
+// values it points to; otherwise items are decoded as plain JSON values.
+type JSONCodec struct {
+	New func() interface{}
+}
+
+func (JSONCodec) NewEncoder(w io.Writer) ItemEncoder { return json.NewEncoder(w) }
+
+func (jc JSONCodec) NewDecoder(r io.Reader) ItemDecoder {
+	return jsonItemDecoder{dec: json.NewDecoder(r), newFn: jc.New}
+}
+
+type jsonItemDecoder struct {
+	dec   *json.Decoder
+	newFn func() interface{}
+}
+
+func (jd jsonItemDecoder) Decode() (interface{}, error) {
+	target := newJSONTarget(jd.newFn)
+	if err := jd.dec.Decode(target); err != nil {
+		return nil, err
+	}
+	return jsonTargetValue(target), nil
+}
+
+// newJSONTarget returns a pointer to decode a JSON value into: the result of
+// newFn or, if it is nil, a pointer to an empty interface.
+func newJSONTarget(newFn func() interface{}) interface{} {
+	if newFn == nil {
+		return new(interface{})
+	}
+	return newFn()
+}
+
+func jsonTargetValue(target interface{}) interface{} {
+	return reflect.ValueOf(target).Elem().Interface()
+}
@@ -0,0 +1,336 @@
+package streamer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// CSVOptions configures a CSVIterator. Records are yielded as []string by
+// default, as map[string]string if Header is set, or as structs if New is
+// set. New must return a pointer to a new struct; its fields are matched to
+// the header by their csv tag or, if they have none, by their name. Fields
+// tagged csv:"-" are ignored.
+type CSVOptions struct {
+	Comma  rune
+	Header bool
+	New    func() interface{}
+}
+
+// CSVFieldError is reported when a field can not be converted to the type of
+// its struct field.
+type CSVFieldError struct {
+	Record int
+	Field  string
+	Err    error
+}
+
+func (e *CSVFieldError) Error() string {
+	return fmt.Sprintf("streamer: csv: record %d, field %q: %v", e.Record, e.Field, e.Err)
+}
+
+func (e *CSVFieldError) Unwrap() error { return e.Err }
+
+var errCSVHeaderRequired = errors.New("streamer: csv: a header is required to write maps")
+
+type CSVIterator struct {
+	reader  io.Reader
+	csv     *csv.Reader
+	options CSVOptions
+	// base is the offset in reader where csv started reading
+	base int64
+
+	header  []string
+	columns map[string]int
+	record  int
+	err     error
+}
+
+func NewCSVIterator(r io.Reader, options CSVOptions) *CSVIterator {
+	if options.New != nil {
+		options.Header = true
+	}
+
+	res := &CSVIterator{
+		reader:  r,
+		options: options,
+	}
+	res.resetReader(0)
+	return res
+}
+
+func (ci *CSVIterator) resetReader(base int64) {
+	ci.csv = csv.NewReader(ci.reader)
+	if ci.options.Comma != 0 {
+		ci.csv.Comma = ci.options.Comma
+	}
+	ci.base = base
+}
+
+func (ci *CSVIterator) Next() (interface{}, bool) {
+	if ci.err != nil {
+		return nil, false
+	}
+
+	if ci.options.Header && ci.header == nil && !ci.readHeader() {
+		return nil, false
+	}
+
+	record, ok := ci.read()
+	if !ok {
+		return nil, false
+	}
+
+	switch {
+	case ci.options.New != nil:
+		return ci.decodeStruct(record)
+	case ci.options.Header:
+		item := make(map[string]string, len(ci.header))
+		for i, name := range ci.header {
+			if i < len(record) {
+				item[name] = record[i]
+			}
+		}
+		return item, true
+	}
+	return record, true
+}
+
+func (ci *CSVIterator) Err() error { return ci.err }
+
+// Header returns the header record, once it has been read.
+func (ci *CSVIterator) Header() []string { return ci.header }
+
+// Close closes the underlying reader if it is an io.Closer.
+func (ci *CSVIterator) Close() error {
+	if closer, ok := ci.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Snapshot returns the byte offset after the last record, and the number of
+// records read, header included. Restoring it requires the reader to be an
+// io.Seeker; the header is read again from the start.
+func (ci *CSVIterator) Snapshot() (*State, error) {
+	return &State{Kind: "csv", Offset: ci.base + ci.csv.InputOffset(), Count: ci.record}, nil
+}
+
+func (ci *CSVIterator) Restore(state *State) error {
+	if err := checkState(state, "csv"); err != nil {
+		return err
+	}
+	seeker, ok := ci.reader.(io.Seeker)
+	if !ok {
+		return ErrNotSnapshottable
+	}
+
+	ci.header, ci.columns, ci.record, ci.err = nil, nil, 0, nil
+	if ci.options.Header && state.Count > 0 {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		ci.resetReader(0)
+		if !ci.readHeader() {
+			if ci.err == nil {
+				ci.err = io.ErrUnexpectedEOF
+			}
+			return ci.err
+		}
+	}
+
+	if _, err := seeker.Seek(state.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	ci.resetReader(state.Offset)
+	ci.record = state.Count
+	return nil
+}
+
+func (ci *CSVIterator) readHeader() bool {
+	header, ok := ci.read()
+	if !ok {
+		return false
+	}
+	ci.header = header
+	ci.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		ci.columns[name] = i
+	}
+	return true
+}
+
+func (ci *CSVIterator) read() ([]string, bool) {
+	record, err := ci.csv.Read()
+	if err != nil {
+		if err != io.EOF {
+			ci.err = err
+		}
+		return nil, false
+	}
+	ci.record++
+	return record, true
+}
+
+func (ci *CSVIterator) decodeStruct(record []string) (interface{}, bool) {
+	target := ci.options.New()
+	value := reflect.ValueOf(target).Elem()
+
+	for _, field := range csvFields(value.Type()) {
+		column, ok := ci.columns[field.name]
+		if !ok || column >= len(record) {
+			continue
+		}
+		if err := setCSVField(value.Field(field.index), record[column]); err != nil {
+			ci.err = &CSVFieldError{Record: ci.record, Field: field.name, Err: err}
+			return nil, false
+		}
+	}
+
+	return value.Interface(), true
+}
+
+//
+
+type csvField struct {
+	name  string
+	index int
+}
+
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{name: name, index: i})
+	}
+	return fields
+}
+
+func setCSVField(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+	return nil
+}
+
+// CSV returns a stream of the records of r.
+func CSV(r io.Reader, options CSVOptions) *Stream {
+	return NewStream(NewCSVIterator(r, options))
+}
+
+//
+
+func writeCSV(st *Stream, w io.Writer, header []string) (err error) {
+	var (
+		writer        = csv.NewWriter(w)
+		headerWritten bool
+	)
+	// the records written before a failure are flushed too
+	defer func() {
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+	}()
+
+	writeHeader := func() error {
+		if headerWritten || header == nil {
+			return nil
+		}
+		headerWritten = true
+		return writer.Write(header)
+	}
+
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		var record []string
+
+		switch item := item.(type) {
+		case []string:
+			record = item
+		case map[string]string:
+			if header == nil {
+				return errCSVHeaderRequired
+			}
+			record = make([]string, len(header))
+			for i, name := range header {
+				record[i] = item[name]
+			}
+		default:
+			value := reflect.ValueOf(item)
+			if value.Kind() == reflect.Ptr {
+				value = value.Elem()
+			}
+			if value.Kind() != reflect.Struct {
+				return fmt.Errorf("streamer: csv: unsupported record type %T", item)
+			}
+
+			fields := csvFields(value.Type())
+			if header == nil {
+				for _, field := range fields {
+					header = append(header, field.name)
+				}
+			}
+
+			byName := make(map[string]string, len(fields))
+			for _, field := range fields {
+				byName[field.name] = fmt.Sprint(value.Field(field.index).Interface())
+			}
+			record = make([]string, len(header))
+			for i, name := range header {
+				record[i] = byName[name]
+			}
+		}
+
+		if err := writeHeader(); err != nil {
+			return err
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := writeHeader(); err != nil {
+		return err
+	}
+	return st.Err()
+}
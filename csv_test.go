@@ -0,0 +1,151 @@
+package streamer_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type person struct {
+	Name    string  `csv:"name"`
+	Age     int     `csv:"age"`
+	Score   float64 `csv:"score"`
+	Active  bool
+	Ignored string `csv:"-"`
+}
+
+func Test_csv(t *testing.T) {
+	const input = "name,age,score,Active\nalice,30,1.5,true\nbob,25,2,false\n"
+
+	t.Run("yields records as string slices", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.CSV(strings.NewReader(input), streamer.CSVOptions{})
+
+		records := collect(stream)
+		assert.NoError(stream.Err())
+		assert.Len(records, 3)
+		assert.Equal([]string{"alice", "30", "1.5", "true"}, records[1])
+	})
+
+	t.Run("yields records as maps", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = "a;b\n1;2\n3;4\n"
+			expectedOutput = []interface{}{
+				map[string]string{"a": "1", "b": "2"},
+				map[string]string{"a": "3", "b": "4"},
+			}
+		)
+
+		stream := streamer.CSV(strings.NewReader(input), streamer.CSVOptions{Comma: ';', Header: true})
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("yields records as structs", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			expectedOutput = []interface{}{
+				person{Name: "alice", Age: 30, Score: 1.5, Active: true},
+				person{Name: "bob", Age: 25, Score: 2},
+			}
+		)
+
+		stream := streamer.CSV(strings.NewReader(input), streamer.CSVOptions{New: func() interface{} { return new(person) }})
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("reports conversion errors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.CSV(strings.NewReader("name,age\nalice,30\nbob,x\n"), streamer.CSVOptions{New: func() interface{} { return new(person) }})
+
+		assert.Len(collect(stream), 1)
+
+		fieldErr, ok := stream.Err().(*streamer.CSVFieldError)
+		assert.True(ok)
+		assert.Equal(3, fieldErr.Record)
+		assert.Equal("age", fieldErr.Field)
+		_, isNumErr := fieldErr.Err.(*strconv.NumError)
+		assert.True(isNumErr)
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.CSV(strings.NewReader("a,b\n1,2,3\n"), streamer.CSVOptions{Header: true})
+
+		assert.Empty(collect(stream))
+		assert.Error(stream.Err())
+	})
+
+	t.Run("writes structs with a derived header", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			buf bytes.Buffer
+		)
+
+		err := streamer.CSV(strings.NewReader(input), streamer.CSVOptions{New: func() interface{} { return new(person) }}).
+			Filter(func(x interface{}) bool { return x.(person).Active }).
+			WriteCSV(&buf, nil)
+
+		assert.NoError(err)
+		assert.Equal("name,age,score,Active\nalice,30,1.5,true\n", buf.String())
+	})
+
+	t.Run("writes maps and slices in header order", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{
+				map[string]string{"b": "2", "a": "1"},
+				[]string{"3", "4"},
+			}
+			buf bytes.Buffer
+		)
+
+		err := streamer.NewStream(streamer.NewSliceIterator(input)).WriteCSV(&buf, []string{"a", "b"})
+
+		assert.NoError(err)
+		assert.Equal("a,b\n1,2\n3,4\n", buf.String())
+	})
+
+	t.Run("writing maps requires a header", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{map[string]string{"a": "1"}}
+			buf   bytes.Buffer
+		)
+
+		err := streamer.NewStream(streamer.NewSliceIterator(input)).WriteCSV(&buf, nil)
+
+		assert.Error(err)
+	})
+
+	t.Run("keeps the records written before an error", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{[]string{"1", "2"}, map[string]string{"a": "3"}}
+			buf   bytes.Buffer
+		)
+
+		err := streamer.NewStream(streamer.NewSliceIterator(input)).WriteCSV(&buf, nil)
+
+		assert.Error(err)
+		assert.Equal("1,2\n", buf.String())
+	})
+}
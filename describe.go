@@ -0,0 +1,120 @@
+package streamer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Named names the last stage of the stream, for Describe, DOT and observers.
+func (st *Stream) Named(name string) *Stream {
+	res := *st
+	res.name = name
+	res.renameWrappers()
+	return &res
+}
+
+// Describe renders the chain of stages, like "Slice -> Filter -> Map -> Take(10)".
+// Named stages are rendered as Map[parse].
+func (st *Stream) Describe() string {
+	var labels []string
+	for _, s := range st.chain() {
+		labels = append(labels, s.stageName())
+	}
+	return strings.Join(labels, " -> ")
+}
+
+func (st *Stream) String() string { return st.Describe() }
+
+// DOT renders the chain of stages as a Graphviz graph.
+func (st *Stream) DOT() string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph stream {\n\trankdir=LR;\n")
+	chain := st.chain()
+	for i, s := range chain {
+		fmt.Fprintf(&sb, "\ts%d [label=%s];\n", i, strconv.Quote(s.stageName()))
+	}
+	for i := 1; i < len(chain); i++ {
+		fmt.Fprintf(&sb, "\ts%d -> s%d;\n", i-1, i)
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// chain returns the streams from the source to st.
+func (st *Stream) chain() []*Stream {
+	var res []*Stream
+	for s := st; s != nil; s = s.upstream {
+		res = append([]*Stream{s}, res...)
+	}
+	return res
+}
+
+func (st *Stream) stageLabel() string {
+	if st.label != "" {
+		return st.label
+	}
+	return sourceLabel(st.input)
+}
+
+func (st *Stream) stageName() string {
+	if st.name != "" {
+		return st.stageLabel() + "[" + st.name + "]"
+	}
+	return st.stageLabel()
+}
+
+// renameWrappers updates the stage name kept by the iterators wrapping the
+// last stage.
+func (st *Stream) renameWrappers() {
+	it := st.input
+	if observed, ok := it.(*observedIterator); ok {
+		observed.stage.Name = st.stageName()
+		it = observed.input
+	}
+	if recovering, ok := it.(*recoveringIterator); ok {
+		recovering.stage = st.stageName()
+	}
+}
+
+func sourceLabel(it Iterator) string {
+	switch it := it.(type) {
+	case *Stream:
+		return it.Describe()
+	case *observedIterator:
+		return sourceLabel(it.input)
+	case *recoveringIterator:
+		return sourceLabel(it.input)
+	case *SliceIterator:
+		return "Slice"
+	case *ChannelIterator:
+		return "Channel"
+	case *rangeIterator:
+		return fmt.Sprintf("Range(%d, %d, %d)", it.start, it.end, it.step)
+	case *iterateIterator:
+		return "Iterate"
+	case *unfoldIterator:
+		return "Unfold"
+	case generateIterator:
+		return "Generate"
+	case *ReaderIterator:
+		return "Reader"
+	case *JSONLinesIterator:
+		return "JSONLines"
+	case *CSVIterator:
+		return "CSV"
+	case *walkDirIterator:
+		return fmt.Sprintf("WalkDir(%q)", it.root)
+	case *RowsIterator:
+		return "Rows"
+	case *Peekable:
+		return "Peekable(" + sourceLabel(it.input) + ")"
+	case *Replayable:
+		return "Replayable(" + sourceLabel(it.input) + ")"
+	case *failedIterator:
+		return "Failed"
+	}
+	return fmt.Sprintf("%T", it)
+}
@@ -0,0 +1,77 @@
+package streamer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_describe(t *testing.T) {
+	var (
+		filterFn = func(x interface{}) bool { return true }
+		mapFn    = func(x interface{}) interface{} { return x }
+	)
+
+	t.Run("describes the chain of stages", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(nil)).
+			Filter(filterFn).
+			Map(mapFn).
+			Take(10)
+
+		assert.Equal("Slice -> Filter -> Map -> Take(10)", stream.Describe())
+		assert.Equal(stream.Describe(), stream.String())
+	})
+
+	t.Run("describes named stages and sources", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.Lines(strings.NewReader("")).
+			Map(mapFn).Named("parse").
+			Skip(1).
+			ChunkEvery(100).
+			Keys()
+
+		assert.Equal("Reader -> Map[parse] -> Skip(1) -> ChunkEvery(100) -> Keys", stream.Describe())
+		assert.Equal("Range(0, 5, 1) -> CycleN(2)", streamer.Range(0, 5, 1).CycleN(2).Describe())
+	})
+
+	t.Run("names observed stages", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			observer = streamer.NewMemoryObserver()
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{1})).
+			Observe(observer).
+			Map(mapFn).Named("parse")
+
+		assert.Equal("Slice -> Map[parse]", stream.Describe())
+
+		collect(stream)
+
+		metrics := observer.Metrics()
+		assert.Len(metrics, 2)
+		assert.Equal("Slice", metrics[0].Stage.Name)
+		assert.Equal("Map[parse]", metrics[1].Stage.Name)
+	})
+
+	t.Run("exports the chain as a graph", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(nil)).Filter(filterFn).Named("valid")
+
+		expected := "digraph stream {\n" +
+			"\trankdir=LR;\n" +
+			"\ts0 [label=\"Slice\"];\n" +
+			"\ts1 [label=\"Filter[valid]\"];\n" +
+			"\ts0 -> s1;\n" +
+			"}\n"
+		assert.Equal(expected, stream.DOT())
+	})
+}
@@ -0,0 +1,105 @@
+package streamer
+
+// Range returns a stream of the integers from start up to, but not including,
+// end, advancing by step. A negative step counts down; a zero step yields
+// nothing.
+func Range(start, end, step int) *Stream {
+	return NewStream(&rangeIterator{start: start, next: start, end: end, step: step})
+}
+
+// Repeat returns an infinite stream of x.
+func Repeat(x interface{}) *Stream {
+	return Generate(func() interface{} { return x })
+}
+
+// RepeatN returns a stream of x, n times; a negative n is taken as 0.
+func RepeatN(x interface{}, n int) *Stream {
+	if n < 0 {
+		n = 0
+	}
+	return Repeat(x).Take(n)
+}
+
+// Iterate returns the infinite stream seed, fn(seed), fn(fn(seed)), ...
+func Iterate(seed interface{}, fn func(interface{}) interface{}) *Stream {
+	return NewStream(&iterateIterator{current: seed, fn: fn})
+}
+
+// Unfold returns a stream built from a state: fn returns the next element
+// and the next state, or false to end the stream.
+func Unfold(state interface{}, fn func(state interface{}) (item interface{}, next interface{}, ok bool)) *Stream {
+	return NewStream(&unfoldIterator{state: state, fn: fn})
+}
+
+// Generate returns an infinite stream of the values returned by fn.
+func Generate(fn func() interface{}) *Stream {
+	return NewStream(generateIterator(fn))
+}
+
+//
+
+type rangeIterator struct {
+	start int
+	next  int
+	end   int
+	step  int
+	done  bool
+}
+
+func (ri *rangeIterator) Next() (interface{}, bool) {
+	if ri.done || ri.step == 0 ||
+		(ri.step > 0 && ri.next >= ri.end) ||
+		(ri.step < 0 && ri.next <= ri.end) {
+		return nil, false
+	}
+	item := ri.next
+
+	// the distances are computed as uint, so they do not overflow; advancing
+	// past end could
+	if ri.step > 0 {
+		ri.done = uint(ri.end)-uint(ri.next) <= uint(ri.step)
+	} else {
+		ri.done = uint(ri.next)-uint(ri.end) <= -uint(ri.step)
+	}
+	if !ri.done {
+		ri.next += ri.step
+	}
+	return item, true
+}
+
+type iterateIterator struct {
+	current interface{}
+	fn      func(interface{}) interface{}
+	started bool
+}
+
+func (ii *iterateIterator) Next() (interface{}, bool) {
+	if ii.started {
+		ii.current = ii.fn(ii.current)
+	}
+	ii.started = true
+	return ii.current, true
+}
+
+type unfoldIterator struct {
+	state interface{}
+	fn    func(interface{}) (interface{}, interface{}, bool)
+	done  bool
+}
+
+func (ui *unfoldIterator) Next() (interface{}, bool) {
+	if ui.done {
+		return nil, false
+	}
+	item, next, ok := ui.fn(ui.state)
+	if !ok {
+		ui.done = true
+		return nil, false
+	}
+	ui.state = next
+	return item, true
+}
+
+type generateIterator func() interface{}
+
+func (gi generateIterator) Next() (interface{}, bool) { return gi(), true }
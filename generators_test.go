@@ -0,0 +1,90 @@
+package streamer_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func collect(iterator streamer.Iterator) []interface{} {
+	var res []interface{}
+	for item, ok := iterator.Next(); ok; item, ok = iterator.Next() {
+		res = append(res, item)
+	}
+	return res
+}
+
+func Test_generators(t *testing.T) {
+	type (
+		expectation struct {
+			stream         *streamer.Stream
+			expectedOutput []interface{}
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{streamer.Range(0, 5, 1), []interface{}{0, 1, 2, 3, 4}},
+			{streamer.Range(0, 10, 3), []interface{}{0, 3, 6, 9}},
+			{streamer.Range(5, 0, -2), []interface{}{5, 3, 1}},
+			{streamer.Range(0, 5, 0), nil},
+			{streamer.Range(5, 5, 1), nil},
+			{streamer.Repeat("x").Take(3), []interface{}{"x", "x", "x"}},
+			{streamer.RepeatN(7, 2), []interface{}{7, 7}},
+			{streamer.RepeatN(7, 0), nil},
+			{streamer.RepeatN(7, -1), nil},
+			{streamer.Range(math.MaxInt-1, math.MaxInt, 5), []interface{}{math.MaxInt - 1}},
+			{streamer.Range(math.MinInt+1, math.MinInt, -5), []interface{}{math.MinInt + 1}},
+			{streamer.Range(math.MinInt, math.MaxInt, math.MaxInt), []interface{}{math.MinInt, -1, math.MaxInt - 1}},
+			{
+				streamer.Iterate(1, func(x interface{}) interface{} { return x.(int) * 2 }).
+					TakeWhile(func(x interface{}) bool { return x.(int) < 20 }),
+				[]interface{}{1, 2, 4, 8, 16},
+			},
+			{
+				streamer.Unfold([2]int{0, 1}, func(state interface{}) (interface{}, interface{}, bool) {
+					fib := state.([2]int)
+					if fib[0] > 10 {
+						return nil, nil, false
+					}
+					return fib[0], [2]int{fib[1], fib[0] + fib[1]}, true
+				}),
+				[]interface{}{0, 1, 1, 2, 3, 5, 8},
+			},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			stream         = exp.stream
+			expectedOutput = exp.expectedOutput
+		)
+
+		t.Run(fmt.Sprintf("generators, test case %v", i+1), func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(expectedOutput, collect(stream))
+		})
+	}
+
+	t.Run("generate calls fn lazily", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			calls = 0
+		)
+
+		stream := streamer.Generate(func() interface{} {
+			calls++
+			return calls
+		})
+
+		assert.Equal(0, calls)
+		assert.Equal([]interface{}{1, 2, 3}, collect(stream.Take(3)))
+		assert.Equal(3, calls)
+	})
+}
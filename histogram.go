@@ -0,0 +1,56 @@
+package streamer
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrHistogramBounds = errors.New("streamer: histograms have different bounds")
+
+// Histogram counts values in fixed buckets. Bounds are the sorted upper
+// bounds (inclusive) of the buckets; Counts has one extra bucket for values
+// above the last bound.
+type Histogram struct {
+	Bounds []float64
+	Counts []int
+}
+
+func NewHistogram(bounds ...float64) *Histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+
+	res := &Histogram{
+		Bounds: sorted,
+		Counts: make([]int, len(sorted)+1),
+	}
+	return res
+}
+
+func (h *Histogram) Add(x float64) {
+	h.Counts[sort.SearchFloat64s(h.Bounds, x)]++
+}
+
+func (h *Histogram) Total() int {
+	total := 0
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// Merge adds the counts of other into h. Both must have the same bounds.
+func (h *Histogram) Merge(other *Histogram) error {
+	if len(h.Bounds) != len(other.Bounds) {
+		return ErrHistogramBounds
+	}
+	for i := range h.Bounds {
+		if h.Bounds[i] != other.Bounds[i] {
+			return ErrHistogramBounds
+		}
+	}
+
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	return nil
+}
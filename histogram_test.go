@@ -0,0 +1,51 @@
+package streamer_test
+
+import (
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_histogram(t *testing.T) {
+	t.Run("counts elements in buckets", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = []interface{}{1, 5, 10, 11, 50, 100, 250, 3.5}
+			stream = streamer.NewStream(streamer.NewSliceIterator(input))
+		)
+
+		histogram, err := stream.Histogram(100, 10, 50)
+		assert.NoError(err)
+		assert.Equal([]float64{10, 50, 100}, histogram.Bounds)
+		assert.Equal([]int{4, 2, 1, 1}, histogram.Counts)
+		assert.Equal(len(input), histogram.Total())
+	})
+
+	t.Run("merges histograms with the same bounds", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			h1 = streamer.NewHistogram(1, 2)
+			h2 = streamer.NewHistogram(1, 2)
+		)
+
+		h1.Add(0)
+		h2.Add(1.5)
+		h2.Add(3)
+
+		assert.NoError(h1.Merge(h2))
+		assert.Equal([]int{1, 1, 1}, h1.Counts)
+
+		assert.Equal(streamer.ErrHistogramBounds, h1.Merge(streamer.NewHistogram(1, 3)))
+	})
+
+	t.Run("reports non-numeric elements", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := streamer.NewStream(streamer.NewSliceIterator([]interface{}{"x"})).Histogram(1)
+		assert.Error(err)
+	})
+}
@@ -0,0 +1,117 @@
+package streamer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONLineError is reported when a line of a JSON Lines input can not be
+// decoded.
+type JSONLineError struct {
+	Line int
+	Err  error
+}
+
+func (e *JSONLineError) Error() string {
+	return fmt.Sprintf("streamer: json lines: line %d: %v", e.Line, e.Err)
+}
+
+func (e *JSONLineError) Unwrap() error { return e.Err }
+
+// JSONLinesIterator decodes each non-blank line of a JSON Lines (NDJSON)
+// input. If newFn is set, it must return a pointer to a new value of the
+// target type, and the items are the values it points to; otherwise objects
+// are decoded as map[string]interface{}.
+type JSONLinesIterator struct {
+	lines *ReaderIterator
+	newFn func() interface{}
+
+	line int
+	err  error
+}
+
+// NewJSONLinesIterator creates a JSONLinesIterator. A maxLineSize <= 0
+// defaults to bufio.MaxScanTokenSize.
+func NewJSONLinesIterator(r io.Reader, newFn func() interface{}, maxLineSize int) *JSONLinesIterator {
+	res := &JSONLinesIterator{
+		lines: NewReaderIterator(r, bufio.ScanLines, maxLineSize),
+		newFn: newFn,
+	}
+	return res
+}
+
+func (jl *JSONLinesIterator) Next() (interface{}, bool) {
+	if jl.err != nil {
+		return nil, false
+	}
+
+	for item, ok := jl.lines.Next(); ok; item, ok = jl.lines.Next() {
+		jl.line++
+
+		line := item.(string)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		target := newJSONTarget(jl.newFn)
+		if err := json.Unmarshal([]byte(line), target); err != nil {
+			jl.err = &JSONLineError{Line: jl.line, Err: err}
+			return nil, false
+		}
+		return jsonTargetValue(target), true
+	}
+
+	if err := jl.lines.Err(); err != nil {
+		jl.err = &JSONLineError{Line: jl.line + 1, Err: err}
+	}
+	return nil, false
+}
+
+func (jl *JSONLinesIterator) Err() error { return jl.err }
+
+// Close closes the underlying reader if it is an io.Closer.
+func (jl *JSONLinesIterator) Close() error { return jl.lines.Close() }
+
+func (jl *JSONLinesIterator) Snapshot() (*State, error) {
+	return stageSnapshot("json-lines", jl.line, jl.lines)
+}
+
+func (jl *JSONLinesIterator) Restore(state *State) error {
+	line, err := restoreStage("json-lines", state, jl.lines)
+	if err != nil {
+		return err
+	}
+	jl.line = line
+	jl.err = nil
+	return nil
+}
+
+// JSONLines returns a stream of the values decoded from the lines of r.
+func JSONLines(r io.Reader, newFn func() interface{}) *Stream {
+	return NewStream(NewJSONLinesIterator(r, newFn, 0))
+}
+
+//
+
+func writeJSONLines(st *Stream, w io.Writer) (err error) {
+	var (
+		buffered = bufio.NewWriter(w)
+		enc      = json.NewEncoder(buffered)
+	)
+	// the lines encoded before a failure are written too
+	defer func() {
+		if flushErr := buffered.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,112 @@
+package streamer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type event struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func Test_json_lines(t *testing.T) {
+	t.Run("decodes lines into maps", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = "{\"id\":1}\n\n{\"id\":2,\"tags\":[\"a\"]}\n"
+			expectedOutput = []interface{}{
+				map[string]interface{}{"id": 1.0},
+				map[string]interface{}{"id": 2.0, "tags": []interface{}{"a"}},
+			}
+		)
+
+		stream := streamer.JSONLines(strings.NewReader(input), nil)
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("decodes lines into a target type", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}"
+			expectedOutput = []interface{}{event{1, "a"}, event{2, "b"}}
+		)
+
+		stream := streamer.JSONLines(strings.NewReader(input), func() interface{} { return new(event) })
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("reports the line number of a decode error", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = "{\"id\":1}\n\n{\"id\":\n{\"id\":4}"
+		)
+
+		stream := streamer.JSONLines(strings.NewReader(input), nil)
+
+		assert.Len(collect(stream), 1)
+
+		lineErr, ok := stream.Err().(*streamer.JSONLineError)
+		assert.True(ok)
+		assert.Equal(3, lineErr.Line)
+		_, isSyntaxErr := lineErr.Err.(*json.SyntaxError)
+		assert.True(isSyntaxErr)
+	})
+
+	t.Run("writes json lines", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{event{1, "a"}, map[string]interface{}{"id": 2}, 3}
+			buf   bytes.Buffer
+		)
+
+		err := streamer.NewStream(streamer.NewSliceIterator(input)).WriteJSONLines(&buf)
+
+		assert.NoError(err)
+		assert.Equal("{\"id\":1,\"name\":\"a\"}\n{\"id\":2}\n3\n", buf.String())
+	})
+
+	t.Run("keeps the lines written before an encode error", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{1, func() {}, 3}
+			buf   bytes.Buffer
+		)
+
+		err := streamer.NewStream(streamer.NewSliceIterator(input)).WriteJSONLines(&buf)
+
+		assert.Error(err)
+		assert.Equal("1\n", buf.String())
+	})
+
+	t.Run("round trips through a pipeline", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n{\"id\":3,\"name\":\"c\"}\n"
+			buf   bytes.Buffer
+		)
+
+		err := streamer.JSONLines(strings.NewReader(input), func() interface{} { return new(event) }).
+			Filter(func(x interface{}) bool { return x.(event).ID != 2 }).
+			WriteJSONLines(&buf)
+
+		assert.NoError(err)
+		assert.Equal("{\"id\":1,\"name\":\"a\"}\n{\"id\":3,\"name\":\"c\"}\n", buf.String())
+	})
+}
@@ -0,0 +1,155 @@
+package streamer
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stage identifies a stage of an observed stream; Index is its position,
+// starting from 0 for the source.
+type Stage struct {
+	Index int
+	Name  string
+}
+
+// StageSample describes one Next call of a stage. Callback is the time spent
+// in the stage itself, mostly in its callback; Upstream is the time it was
+// blocked waiting for the previous stage.
+type StageSample struct {
+	ItemsIn  int
+	ItemsOut int
+	Callback time.Duration
+	Upstream time.Duration
+}
+
+// Observer receives per-stage samples from a stream set up with Observe.
+// Stages before a Buffer or Timeout stage are pulled in another goroutine, so
+// their samples are observed concurrently with the others.
+type Observer interface {
+	ObserveNext(stage Stage, sample StageSample)
+}
+
+// StageMetrics accumulates the samples of a stage.
+type StageMetrics struct {
+	Stage    Stage
+	Calls    int
+	ItemsIn  int
+	ItemsOut int
+	Callback time.Duration
+	Upstream time.Duration
+}
+
+// MemoryObserver is an Observer that accumulates metrics in memory.
+type MemoryObserver struct {
+	mu      sync.Mutex
+	metrics map[Stage]*StageMetrics
+}
+
+func NewMemoryObserver() *MemoryObserver {
+	res := &MemoryObserver{
+		metrics: make(map[Stage]*StageMetrics),
+	}
+	return res
+}
+
+func (mo *MemoryObserver) ObserveNext(stage Stage, sample StageSample) {
+	mo.mu.Lock()
+	defer mo.mu.Unlock()
+
+	metrics, ok := mo.metrics[stage]
+	if !ok {
+		metrics = &StageMetrics{Stage: stage}
+		mo.metrics[stage] = metrics
+	}
+	metrics.Calls++
+	metrics.ItemsIn += sample.ItemsIn
+	metrics.ItemsOut += sample.ItemsOut
+	metrics.Callback += sample.Callback
+	metrics.Upstream += sample.Upstream
+}
+
+// Metrics returns the metrics of all stages, ordered by stage index.
+func (mo *MemoryObserver) Metrics() []StageMetrics {
+	mo.mu.Lock()
+	defer mo.mu.Unlock()
+
+	res := make([]StageMetrics, 0, len(mo.metrics))
+	for _, metrics := range mo.metrics {
+		res = append(res, *metrics)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Stage.Index < res[j].Stage.Index })
+	return res
+}
+
+//
+
+type observedIterator struct {
+	input    Iterator
+	stage    Stage
+	observer Observer
+	upstream *observedIterator
+	clock    Clock
+
+	// detached is set for stages that pull their input in another goroutine,
+	// like Buffer: the counters of upstream are not read, and the time spent
+	// waiting for it is reported as Callback
+	detached bool
+
+	elapsed  time.Duration
+	itemsOut int
+}
+
+func newObservedIterator(input Iterator, name string, observer Observer, upstream *observedIterator, clock Clock) (res *observedIterator) {
+	res = &observedIterator{
+		input:    input,
+		stage:    Stage{Name: name},
+		observer: observer,
+		upstream: upstream,
+		clock:    clock,
+	}
+	if upstream != nil {
+		res.stage.Index = upstream.stage.Index + 1
+	}
+	return
+}
+
+func (oi *observedIterator) Next() (interface{}, bool) {
+	var (
+		upstreamElapsed  time.Duration
+		upstreamItemsOut int
+	)
+	linked := oi.upstream != nil && !oi.detached
+	if linked {
+		upstreamElapsed, upstreamItemsOut = oi.upstream.elapsed, oi.upstream.itemsOut
+	}
+
+	start := oi.clock.Now()
+	item, ok := oi.input.Next()
+	elapsed := oi.clock.Now().Sub(start)
+	oi.elapsed += elapsed
+
+	var sample StageSample
+	if ok {
+		oi.itemsOut++
+		sample.ItemsOut = 1
+	}
+	if linked {
+		sample.ItemsIn = oi.upstream.itemsOut - upstreamItemsOut
+		sample.Upstream = oi.upstream.elapsed - upstreamElapsed
+	} else {
+		sample.ItemsIn = sample.ItemsOut
+	}
+	sample.Callback = elapsed - sample.Upstream
+
+	oi.observer.ObserveNext(oi.stage, sample)
+	return item, ok
+}
+
+func (oi *observedIterator) Err() error { return iteratorErr(oi.input) }
+
+func (oi *observedIterator) Close() error { return closeIterator(oi.input) }
+
+func (oi *observedIterator) Snapshot() (*State, error) { return snapshotOf(oi.input) }
+
+func (oi *observedIterator) Restore(state *State) error { return restoreTo(oi.input, state) }
@@ -0,0 +1,110 @@
+package streamer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dc0d/streamer"
+	"github.com/dc0d/streamer/streamertest"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_observer(t *testing.T) {
+	t.Run("records per-stage metrics", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			observer = streamer.NewMemoryObserver()
+			clock    = streamertest.NewFakeClock()
+			delay    = time.Millisecond
+		)
+
+		stream := streamer.Range(0, 10, 1).
+			WithClock(clock).
+			Observe(observer).
+			Map(func(x interface{}) interface{} {
+				clock.Advance(delay)
+				return x
+			}).
+			Filter(func(x interface{}) bool { return x.(int)%2 == 0 }).
+			Take(3)
+
+		assert.Equal([]interface{}{0, 2, 4}, collect(stream))
+
+		metrics := observer.Metrics()
+		assert.Len(metrics, 4)
+
+		var names []string
+		for i, m := range metrics {
+			assert.Equal(i, m.Stage.Index)
+			names = append(names, m.Stage.Name)
+		}
+		assert.Equal([]string{"Range(0, 10, 1)", "Map", "Filter", "Take(3)"}, names)
+
+		source, mapper, filter, take := metrics[0], metrics[1], metrics[2], metrics[3]
+
+		assert.Equal(5, source.ItemsOut)
+		assert.Equal(5, mapper.ItemsIn)
+		assert.Equal(5, mapper.ItemsOut)
+		assert.Equal(5, filter.ItemsIn)
+		assert.Equal(3, filter.ItemsOut)
+		assert.Equal(3, take.ItemsIn)
+		assert.Equal(3, take.ItemsOut)
+		assert.Equal(4, take.Calls)
+
+		assert.Equal(5*delay, mapper.Callback)
+		assert.Equal(time.Duration(0), mapper.Upstream)
+		assert.Equal(5*delay, filter.Upstream)
+		assert.Equal(time.Duration(0), filter.Callback)
+		assert.Equal(5*delay, take.Upstream)
+	})
+
+	t.Run("keeps errors and closing of observed stages", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			reader = &closeRecorder{Reader: strings.NewReader("{}\n{")}
+		)
+
+		stream := streamer.JSONLines(reader, nil).
+			Observe(streamer.NewMemoryObserver()).
+			Map(func(x interface{}) interface{} { return x })
+
+		assert.Len(collect(stream), 1)
+		assert.Error(stream.Err())
+		assert.NoError(stream.Close())
+		assert.True(reader.closed)
+	})
+
+	t.Run("observes stages that pull in another goroutine", func(t *testing.T) {
+		identity := func(x interface{}) interface{} { return x }
+
+		for _, concurrent := range []func(*streamer.Stream) *streamer.Stream{
+			func(st *streamer.Stream) *streamer.Stream { return st.Buffer(4) },
+			func(st *streamer.Stream) *streamer.Stream { return st.Timeout(time.Hour, streamer.TimeoutFail) },
+		} {
+			var (
+				assert = assert.New(t)
+
+				observer = streamer.NewMemoryObserver()
+			)
+
+			stream := concurrent(streamer.Range(0, 1000, 1).
+				Observe(observer).
+				Map(identity)).
+				Map(identity)
+
+			assert.Len(collect(stream), 1000)
+			assert.NoError(stream.Close())
+
+			metrics := observer.Metrics()
+			assert.Len(metrics, 4)
+			assert.Equal(1000, metrics[2].ItemsIn)
+			assert.Equal(1000, metrics[2].ItemsOut)
+			assert.Equal(time.Duration(0), metrics[2].Upstream)
+			assert.Equal(1000, metrics[3].ItemsIn)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package streamer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Pair is a key/value element, as yielded by FromMap.
+type Pair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// NotPairError is reported by the Pair operators for an element that is not
+// a Pair.
+type NotPairError struct {
+	Item interface{}
+}
+
+func (e *NotPairError) Error() string {
+	return fmt.Sprintf("streamer: element %v (%T) is not a Pair", e.Item, e.Item)
+}
+
+// UnhashableKeyError is reported by ToMap for a key that can not be used as a
+// map key, like a slice.
+type UnhashableKeyError struct {
+	Key interface{}
+}
+
+func (e *UnhashableKeyError) Error() string {
+	return fmt.Sprintf("streamer: unhashable key %v (%T)", e.Key, e.Key)
+}
+
+// FromMap returns a stream of the entries of m as Pair elements in
+// unspecified order. If m is not a map, the stream is empty and Err reports
+// the error.
+func FromMap(m interface{}) *Stream {
+	pairs, err := mapPairs(m)
+	if err != nil {
+		return NewStream(&failedIterator{err: err})
+	}
+	return NewStream(NewSliceIterator(pairs))
+}
+
+// FromMapSorted is like FromMap, with the Pair elements ordered by key. A nil
+// lessFn orders numbers numerically, and other keys by their string
+// representation.
+func FromMapSorted(m interface{}, lessFn func(a, b interface{}) bool) *Stream {
+	if lessFn == nil {
+		lessFn = naturalLess
+	}
+	pairs, err := mapPairs(m)
+	if err != nil {
+		return NewStream(&failedIterator{err: err})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return lessFn(pairs[i].(Pair).Key, pairs[j].(Pair).Key)
+	})
+	return NewStream(NewSliceIterator(pairs))
+}
+
+func mapPairs(m interface{}) ([]interface{}, error) {
+	value := reflect.ValueOf(m)
+	if value.Kind() != reflect.Map {
+		return nil, fmt.Errorf("streamer: FromMap called with %T, not a map", m)
+	}
+
+	pairs := make([]interface{}, 0, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		pairs = append(pairs, Pair{Key: iter.Key().Interface(), Value: iter.Value().Interface()})
+	}
+	return pairs, nil
+}
+
+// failedIterator is an empty source that reports err.
+type failedIterator struct {
+	err error
+}
+
+func (fi *failedIterator) Next() (interface{}, bool) { return nil, false }
+
+func (fi *failedIterator) Err() error { return fi.err }
+
+func naturalLess(a, b interface{}) bool {
+	x, errX := toFloat64(a)
+	y, errY := toFloat64(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func toMap(st *Stream) (map[interface{}]interface{}, error) {
+	res := make(map[interface{}]interface{})
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		pair, isPair := item.(Pair)
+		if !isPair {
+			return nil, &NotPairError{Item: item}
+		}
+		if err := setKey(res, pair); err != nil {
+			return nil, err
+		}
+	}
+	if err := st.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func setKey(m map[interface{}]interface{}, pair Pair) (err error) {
+	// keys like arrays of interfaces are only found unhashable when hashed
+	defer func() {
+		if r := recover(); r != nil {
+			err = &UnhashableKeyError{Key: pair.Key}
+		}
+	}()
+	if pair.Key != nil && !reflect.TypeOf(pair.Key).Comparable() {
+		return &UnhashableKeyError{Key: pair.Key}
+	}
+	m[pair.Key] = pair.Value
+	return nil
+}
@@ -0,0 +1,104 @@
+package streamer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_pair(t *testing.T) {
+	t.Run("streams a map sorted by key", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = map[string]int{"b": 2, "c": 3, "a": 1}
+			expectedOutput = []interface{}{
+				streamer.Pair{Key: "a", Value: 1},
+				streamer.Pair{Key: "b", Value: 2},
+				streamer.Pair{Key: "c", Value: 3},
+			}
+		)
+
+		assert.Equal(expectedOutput, collect(streamer.FromMapSorted(input, nil)))
+	})
+
+	t.Run("sorts numeric keys numerically", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = map[int]string{10: "ten", 9: "nine", 100: "hundred"}
+		)
+
+		assert.Equal([]interface{}{9, 10, 100}, collect(streamer.FromMapSorted(input, nil).Keys()))
+	})
+
+	t.Run("sorts with a custom less function", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = map[string]bool{"bb": true, "a": false, "ccc": true}
+			lessFn = func(a, b interface{}) bool { return len(a.(string)) > len(b.(string)) }
+		)
+
+		assert.Equal([]interface{}{true, true, false}, collect(streamer.FromMapSorted(input, lessFn).Values()))
+	})
+
+	t.Run("transforms and collects pairs", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = map[string]string{"keep-1": "a", "drop": "b", "keep-2": "c"}
+		)
+
+		res, err := streamer.FromMap(input).
+			FilterKeys(func(k interface{}) bool { return strings.HasPrefix(k.(string), "keep") }).
+			MapValues(func(v interface{}) interface{} { return strings.ToUpper(v.(string)) }).
+			ToMap()
+
+		assert.NoError(err)
+		assert.Equal(map[interface{}]interface{}{"keep-1": "A", "keep-2": "C"}, res)
+	})
+
+	t.Run("streams an empty map", func(t *testing.T) {
+		assert := assert.New(t)
+
+		res, err := streamer.FromMap(map[string]int(nil)).ToMap()
+
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+
+	t.Run("reports elements that are not pairs", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := streamer.NewStream(streamer.NewSliceIterator([]interface{}{1})).ToMap()
+		notPair, ok := err.(*streamer.NotPairError)
+		assert.True(ok)
+		assert.Equal(1, notPair.Item)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{streamer.Pair{Key: "a"}, "b"})).Keys()
+		assert.Equal([]interface{}{"a"}, collect(stream))
+		assert.Equal(&streamer.NotPairError{Item: "b"}, stream.Err())
+	})
+
+	t.Run("reports unhashable keys", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for _, key := range []interface{}{[]int{1}, [1]interface{}{[]int{1}}} {
+			_, err := streamer.NewStream(streamer.NewSliceIterator([]interface{}{streamer.Pair{Key: key}})).ToMap()
+			assert.Equal(&streamer.UnhashableKeyError{Key: key}, err)
+		}
+	})
+
+	t.Run("reports a source that is not a map", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.FromMap([]int{1})
+
+		assert.Empty(collect(stream))
+		assert.Error(stream.Err())
+	})
+}
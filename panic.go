@@ -0,0 +1,253 @@
+package streamer
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// PanicPolicy decides what happens when a callback of a stage panics.
+type PanicPolicy int
+
+const (
+	// PanicPropagate lets the panic through; this is the default.
+	PanicPropagate PanicPolicy = iota
+	// PanicAsError ends the stream, and reports the panic through Err.
+	PanicAsError
+	// PanicSkip drops the element that caused the panic, and goes on with
+	// the next one. Skipped panics are reported by Panics. Only panics of callbacks are skipped; panics
+	// of sources, or of stages that can not drop a single element, like
+	// SortExternal, are handled as with PanicAsError.
+	PanicSkip
+)
+
+// maxSkippedPanics is how many skipped panics a stage keeps for Panics.
+const maxSkippedPanics = 100
+
+// PanicError describes a recovered panic. Element is the element passed to
+// the callback, if the panic happened in a callback. Stage is the stage whose
+// callback panicked; panics raised upstream, by the source or by stages added
+// before OnPanic, name the upstream chain, like "Slice -> Map".
+type PanicError struct {
+	Stage   string
+	Element interface{}
+	Value   interface{}
+	Stack   []byte
+
+	// skippable is set for panics of callbacks, which PanicSkip can skip
+	skippable bool
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("streamer: panic in stage %s on element %v: %v", e.Stage, e.Element, e.Value)
+}
+
+// OnPanic sets the panic policy of the stages added after it.
+func (st *Stream) OnPanic(policy PanicPolicy) *Stream {
+	res := *st
+	res.panicPolicy = policy
+	return &res
+}
+
+// Panics returns the panics skipped by stages with the PanicSkip policy; each
+// stage keeps only its first 100. SkippedPanics counts all of them.
+func (st *Stream) Panics() []*PanicError {
+	var res []*PanicError
+	for _, s := range st.chain() {
+		if recovering := findRecovering(s.input); recovering != nil {
+			res = append(res, recovering.skipped...)
+		}
+	}
+	return res
+}
+
+// SkippedPanics returns the number of panics skipped by stages with the
+// PanicSkip policy.
+func (st *Stream) SkippedPanics() int {
+	var res int
+	for _, s := range st.chain() {
+		if recovering := findRecovering(s.input); recovering != nil {
+			res += recovering.skippedCount
+		}
+	}
+	return res
+}
+
+func findRecovering(it Iterator) *recoveringIterator {
+	switch it := it.(type) {
+	case *recoveringIterator:
+		return it
+	case *observedIterator:
+		return findRecovering(it.input)
+	}
+	return nil
+}
+
+//
+
+type recoveringIterator struct {
+	input    Iterator
+	stage    string
+	policy   PanicPolicy
+	upstream *Stream
+
+	skipped      []*PanicError
+	skippedCount int
+	err          error
+}
+
+func newRecoveringIterator(input Iterator, stage string, policy PanicPolicy, upstream *Stream) (res *recoveringIterator) {
+	res = &recoveringIterator{
+		input:    input,
+		stage:    stage,
+		policy:   policy,
+		upstream: upstream,
+	}
+	return
+}
+
+func (ri *recoveringIterator) Next() (interface{}, bool) {
+	for ri.err == nil {
+		item, ok, panicErr := ri.next()
+		if panicErr == nil {
+			return item, ok
+		}
+		if ri.policy == PanicSkip && panicErr.skippable {
+			ri.skippedCount++
+			if len(ri.skipped) < maxSkippedPanics {
+				ri.skipped = append(ri.skipped, panicErr)
+			}
+			continue
+		}
+		ri.err = panicErr
+	}
+	return nil, false
+}
+
+func (ri *recoveringIterator) next() (item interface{}, ok bool, panicErr *PanicError) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		var isPanicErr bool
+		if panicErr, isPanicErr = r.(*PanicError); !isPanicErr {
+			// callbacks of this stage panic with a *PanicError, so this
+			// panic was raised upstream
+			panicErr = &PanicError{Stage: ri.upstream.Describe(), Value: r, Stack: debug.Stack()}
+		}
+		if panicErr.Stage == "" {
+			panicErr.Stage = ri.stage
+		}
+	}()
+
+	item, ok = ri.input.Next()
+	return
+}
+
+func (ri *recoveringIterator) Err() error {
+	if ri.err != nil {
+		return ri.err
+	}
+	return iteratorErr(ri.input)
+}
+
+func (ri *recoveringIterator) Close() error { return closeIterator(ri.input) }
+
+func (ri *recoveringIterator) Snapshot() (*State, error) { return snapshotOf(ri.input) }
+
+func (ri *recoveringIterator) Restore(state *State) error { return restoreTo(ri.input, state) }
+
+//
+
+// elementPanic re-panics a recovered panic of a callback as a *PanicError
+// carrying the element; the stage is filled in by the recoveringIterator.
+func elementPanic(item interface{}) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if panicErr, ok := r.(*PanicError); ok {
+		panic(panicErr)
+	}
+	panic(&PanicError{Element: item, Value: r, Stack: debug.Stack(), skippable: true})
+}
+
+// abortingPanic re-panics a recovered panic as one that PanicSkip can not
+// skip, for stages that can not go on without the element.
+func abortingPanic(r interface{}) {
+	if panicErr, ok := r.(*PanicError); ok {
+		panicErr.skippable = false
+	}
+	panic(r)
+}
+
+func (st *Stream) guardMapFn(fn func(interface{}) interface{}) func(interface{}) interface{} {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(x interface{}) interface{} {
+		defer elementPanic(x)
+		return fn(x)
+	}
+}
+
+func (st *Stream) guardPredicate(fn func(interface{}) bool) func(interface{}) bool {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(x interface{}) bool {
+		defer elementPanic(x)
+		return fn(x)
+	}
+}
+
+func (st *Stream) guardLessFn(fn func(a, b interface{}) bool) func(a, b interface{}) bool {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(a, b interface{}) bool {
+		defer elementPanic(a)
+		return fn(a, b)
+	}
+}
+
+func (st *Stream) guardTapFn(fn func(interface{})) func(interface{}) {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(x interface{}) {
+		defer elementPanic(x)
+		fn(x)
+	}
+}
+
+func (st *Stream) guardFallibleFn(fn func(interface{}) (interface{}, error)) func(interface{}) (interface{}, error) {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(x interface{}) (interface{}, error) {
+		defer elementPanic(x)
+		return fn(x)
+	}
+}
+
+func (st *Stream) guardTimestampFn(fn func(interface{}) time.Time) func(interface{}) time.Time {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(x interface{}) time.Time {
+		defer elementPanic(x)
+		return fn(x)
+	}
+}
+
+func (st *Stream) guardIndexedFn(fn func(int, interface{}) (interface{}, bool)) func(int, interface{}) (interface{}, bool) {
+	if st.panicPolicy == PanicPropagate {
+		return fn
+	}
+	return func(index int, x interface{}) (interface{}, bool) {
+		defer elementPanic(x)
+		return fn(index, x)
+	}
+}
@@ -0,0 +1,158 @@
+package streamer_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_panic_policy(t *testing.T) {
+	var (
+		input  = []interface{}{1, 2, 0, 4}
+		divide = func(x interface{}) interface{} { return 12 / x.(int) }
+	)
+
+	t.Run("propagates panics by default", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).Map(divide)
+
+		assert.Panics(func() { collect(stream) })
+	})
+
+	t.Run("converts panics to errors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			OnPanic(streamer.PanicAsError).
+			Map(divide).Named("divide").
+			Take(10)
+
+		assert.Equal([]interface{}{12, 6}, collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("Map[divide]", panicErr.Stage)
+		assert.Equal(0, panicErr.Element)
+		assert.NotEmpty(panicErr.Stack)
+		assert.Contains(panicErr.Error(), "divide by zero")
+
+		_, ok = stream.Next()
+		assert.False(ok)
+	})
+
+	t.Run("skips elements that panic", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			OnPanic(streamer.PanicSkip).
+			Filter(func(x interface{}) bool { return 12/x.(int) > 0 }).
+			Map(divide)
+
+		assert.Equal([]interface{}{12, 6, 3}, collect(stream))
+		assert.NoError(stream.Err())
+
+		panics := stream.Panics()
+		assert.Len(panics, 1)
+		assert.Equal("Filter", panics[0].Stage)
+		assert.Equal(0, panics[0].Element)
+	})
+
+	t.Run("recovers panics outside callbacks", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(&panickingIterator{}).
+			OnPanic(streamer.PanicAsError).
+			Skip(0)
+
+		assert.Empty(collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("*streamer_test.panickingIterator", panicErr.Stage)
+		assert.Nil(panicErr.Element)
+		assert.Equal("broken source", panicErr.Value)
+	})
+
+	t.Run("skips only the element that panics in a chunk", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{1, 1, 2, 3, 3})).
+			OnPanic(streamer.PanicSkip).
+			ChunkBy(func(x interface{}) interface{} {
+				if x == 2 {
+					panic("bad element")
+				}
+				return x
+			})
+
+		assert.Equal([]interface{}{[]interface{}{1, 1}, []interface{}{3, 3}}, collect(stream))
+		assert.NoError(stream.Err())
+
+		panics := stream.Panics()
+		assert.Len(panics, 1)
+		assert.Equal(2, panics[0].Element)
+	})
+
+	t.Run("does not skip panics of sources", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(&panickingIterator{}).
+			OnPanic(streamer.PanicSkip).
+			Skip(0)
+
+		assert.Empty(collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("broken source", panicErr.Value)
+		assert.Zero(stream.SkippedPanics())
+	})
+
+	t.Run("ends a sort that panics, whatever the policy", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			tempDir = t.TempDir()
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{5, 4, 3, 2, 1})).
+			OnPanic(streamer.PanicSkip).
+			SortExternal(func(a, b interface{}) bool {
+				if a == 1 || b == 1 {
+					panic("bad comparison")
+				}
+				return a.(int) < b.(int)
+			}, streamer.ExternalSortOptions{RunSize: 2, TempDir: tempDir})
+
+		assert.Empty(collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("SortExternal", panicErr.Stage)
+		assert.Equal("bad comparison", panicErr.Value)
+
+		files, err := ioutil.ReadDir(tempDir)
+		assert.NoError(err)
+		assert.Empty(files)
+	})
+
+	t.Run("keeps the first skipped panics, and counts all", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.Range(0, 150, 1).
+			OnPanic(streamer.PanicSkip).
+			Map(func(x interface{}) interface{} { panic("always") })
+
+		assert.Empty(collect(stream))
+		assert.Len(stream.Panics(), 100)
+		assert.Equal(150, stream.SkippedPanics())
+	})
+}
+
+type panickingIterator struct{}
+
+func (panickingIterator) Next() (interface{}, bool) { panic("broken source") }
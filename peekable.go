@@ -0,0 +1,66 @@
+package streamer
+
+// Peekable wraps an iterator with lookahead and pushback, for parsers and
+// custom stages.
+type Peekable struct {
+	input Iterator
+	// buffer holds the elements to be returned before reading input again
+	buffer []interface{}
+}
+
+func NewPeekable(input Iterator) *Peekable {
+	res := &Peekable{input: input}
+	return res
+}
+
+func (pk *Peekable) Next() (interface{}, bool) {
+	if len(pk.buffer) > 0 {
+		item := pk.buffer[0]
+		pk.buffer = pk.buffer[1:]
+		return item, true
+	}
+	return pk.input.Next()
+}
+
+// Peek returns the next element without consuming it.
+func (pk *Peekable) Peek() (interface{}, bool) {
+	items := pk.PeekN(1)
+	if len(items) == 0 {
+		return nil, false
+	}
+	return items[0], true
+}
+
+// PeekN returns up to the next n elements without consuming them. Fewer are
+// returned if the input ends, and none if n <= 0.
+func (pk *Peekable) PeekN(n int) []interface{} {
+	if n <= 0 {
+		return nil
+	}
+	for len(pk.buffer) < n {
+		item, ok := pk.input.Next()
+		if !ok {
+			break
+		}
+		pk.buffer = append(pk.buffer, item)
+	}
+	if n > len(pk.buffer) {
+		n = len(pk.buffer)
+	}
+	return append([]interface{}(nil), pk.buffer[:n]...)
+}
+
+// Unread pushes x back, so it is the next element returned.
+func (pk *Peekable) Unread(x interface{}) {
+	pk.buffer = append([]interface{}{x}, pk.buffer...)
+}
+
+// Err returns the error of the wrapped iterator, if it reports one.
+func (pk *Peekable) Err() error {
+	return iteratorErr(pk.input)
+}
+
+// Close closes the wrapped iterator, if it is an io.Closer.
+func (pk *Peekable) Close() error {
+	return closeIterator(pk.input)
+}
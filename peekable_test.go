@@ -0,0 +1,64 @@
+package streamer_test
+
+import (
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_peekable(t *testing.T) {
+	t.Run("peeks without consuming", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			peekable = streamer.NewPeekable(streamer.NewSliceIterator([]interface{}{1, 2, 3}))
+		)
+
+		item, ok := peekable.Peek()
+		assert.True(ok)
+		assert.Equal(1, item)
+
+		assert.Equal([]interface{}{1, 2}, peekable.PeekN(2))
+		assert.Equal([]interface{}{1, 2, 3}, peekable.PeekN(5))
+		assert.Nil(peekable.PeekN(0))
+		assert.Nil(peekable.PeekN(-1))
+
+		assert.Equal([]interface{}{1, 2, 3}, collect(peekable))
+
+		_, ok = peekable.Peek()
+		assert.False(ok)
+		assert.Empty(peekable.PeekN(1))
+	})
+
+	t.Run("unread pushes elements back", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			peekable = streamer.NewPeekable(streamer.NewSliceIterator([]interface{}{1, 2, 3}))
+		)
+
+		item, _ := peekable.Next()
+		assert.Equal(1, item)
+		peekable.PeekN(2)
+
+		peekable.Unread("b")
+		peekable.Unread("a")
+
+		assert.Equal([]interface{}{"a", "b", 2, 3}, collect(peekable))
+	})
+
+	t.Run("keeps nil elements", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			peekable = streamer.NewPeekable(streamer.NewSliceIterator([]interface{}{nil, 1}))
+		)
+
+		item, ok := peekable.Peek()
+		assert.True(ok)
+		assert.Nil(item)
+		assert.Equal([]interface{}{nil, 1}, collect(peekable))
+	})
+}
@@ -0,0 +1,104 @@
+package streamer
+
+import (
+	"bufio"
+	"io"
+)
+
+// ReaderIterator yields the tokens of an io.Reader as strings, using a
+// bufio.Scanner. Scanner failures, like bufio.ErrTooLong, are reported by Err.
+type ReaderIterator struct {
+	reader       io.Reader
+	split        bufio.SplitFunc
+	maxTokenSize int
+
+	scanner *bufio.Scanner
+	// offset counts the bytes consumed by the returned tokens
+	offset int64
+	err    error
+}
+
+// NewReaderIterator creates a ReaderIterator. A nil split defaults to
+// bufio.ScanLines and a maxTokenSize <= 0 to bufio.MaxScanTokenSize.
+func NewReaderIterator(r io.Reader, split bufio.SplitFunc, maxTokenSize int) *ReaderIterator {
+	if split == nil {
+		split = bufio.ScanLines
+	}
+	if maxTokenSize <= 0 {
+		maxTokenSize = bufio.MaxScanTokenSize
+	}
+
+	res := &ReaderIterator{
+		reader:       r,
+		split:        split,
+		maxTokenSize: maxTokenSize,
+	}
+	res.resetScanner()
+	return res
+}
+
+func (ri *ReaderIterator) Next() (interface{}, bool) {
+	if !ri.scanner.Scan() {
+		ri.err = ri.scanner.Err()
+		return nil, false
+	}
+	return ri.scanner.Text(), true
+}
+
+func (ri *ReaderIterator) Err() error { return ri.err }
+
+// Close closes the underlying reader if it is an io.Closer.
+func (ri *ReaderIterator) Close() error {
+	if closer, ok := ri.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Snapshot returns the byte offset after the last token. Restoring it requires
+// the reader to be an io.Seeker.
+func (ri *ReaderIterator) Snapshot() (*State, error) {
+	return &State{Kind: "reader", Offset: ri.offset}, nil
+}
+
+func (ri *ReaderIterator) Restore(state *State) error {
+	if err := checkState(state, "reader"); err != nil {
+		return err
+	}
+	seeker, ok := ri.reader.(io.Seeker)
+	if !ok {
+		return ErrNotSnapshottable
+	}
+	if _, err := seeker.Seek(state.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	ri.offset = state.Offset
+	ri.err = nil
+	ri.resetScanner()
+	return nil
+}
+
+func (ri *ReaderIterator) resetScanner() {
+	initialSize := 4096
+	if ri.maxTokenSize < initialSize {
+		initialSize = ri.maxTokenSize
+	}
+
+	ri.scanner = bufio.NewScanner(ri.reader)
+	ri.scanner.Buffer(make([]byte, 0, initialSize), ri.maxTokenSize)
+	ri.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := ri.split(data, atEOF)
+		ri.offset += int64(advance)
+		return advance, token, err
+	})
+}
+
+// FromReader returns a stream of the tokens of r, split by splitFunc.
+func FromReader(r io.Reader, splitFunc bufio.SplitFunc) *Stream {
+	return NewStream(NewReaderIterator(r, splitFunc, 0))
+}
+
+// Lines returns a stream of the lines of r.
+func Lines(r io.Reader) *Stream {
+	return FromReader(r, bufio.ScanLines)
+}
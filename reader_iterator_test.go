@@ -0,0 +1,89 @@
+package streamer_test
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+func Test_reader_iterator(t *testing.T) {
+	t.Run("yields lines lazily", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = "first\nsecond\r\n\nlast"
+			expectedOutput = []interface{}{"first", "second", "", "last"}
+		)
+
+		stream := streamer.Lines(strings.NewReader(input))
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("yields tokens of a split func", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = "  one two\nthree  "
+			expectedOutput = []interface{}{"ONE", "TWO", "THREE"}
+		)
+
+		stream := streamer.FromReader(strings.NewReader(input), bufio.ScanWords).
+			Map(func(x interface{}) interface{} { return strings.ToUpper(x.(string)) })
+
+		assert.Equal(expectedOutput, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("reports tokens longer than max token size", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = "short\n" + strings.Repeat("x", 100) + "\nnever"
+		)
+
+		stream := streamer.NewStream(streamer.NewReaderIterator(strings.NewReader(input), nil, 16)).
+			Filter(func(x interface{}) bool { return true })
+
+		assert.Equal([]interface{}{"short"}, collect(stream))
+		assert.Equal(bufio.ErrTooLong, stream.Err())
+	})
+
+	t.Run("closes the reader when the stream is closed", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			reader = &closeRecorder{Reader: strings.NewReader("a\nb")}
+		)
+
+		stream := streamer.Lines(reader).Take(1)
+		collect(stream)
+
+		assert.NoError(stream.Close())
+		assert.True(reader.closed)
+	})
+
+	t.Run("empty reader", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.Lines(ioutil.NopCloser(strings.NewReader("")))
+		assert.Nil(collect(stream))
+		assert.NoError(stream.Close())
+	})
+}
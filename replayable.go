@@ -0,0 +1,124 @@
+package streamer
+
+import "errors"
+
+var (
+	ErrNoMark      = errors.New("streamer: reset without a mark")
+	ErrReplayLimit = errors.New("streamer: replay buffer limit exceeded since mark")
+)
+
+// Rewinder is implemented by iterators that can rewind to a marked position.
+type Rewinder interface {
+	Mark()
+	Reset() error
+}
+
+// ReplayOptions bounds the items a Replayable records since its mark. Zero
+// values mean no limit. SizeFn estimates the size of an item in bytes; by
+// default it is the length of strings and byte slices, and 16 otherwise.
+type ReplayOptions struct {
+	MaxItems int
+	MaxBytes int
+	SizeFn   func(interface{}) int
+}
+
+// Replayable records the elements of an iterator after Mark, so Reset can
+// rewind to the mark without re-reading the source. Iterators that are
+// already a Rewinder, like SliceIterator, are rewound natively.
+type Replayable struct {
+	input   Iterator
+	options ReplayOptions
+
+	marked     bool
+	overflowed bool
+	buffer     []interface{}
+	bytes      int
+	// position is the index in buffer of the next element to replay
+	position int
+}
+
+func NewReplayable(input Iterator, options ReplayOptions) *Replayable {
+	if options.SizeFn == nil {
+		options.SizeFn = defaultItemSize
+	}
+	res := &Replayable{
+		input:   input,
+		options: options,
+	}
+	return res
+}
+
+func (rp *Replayable) Next() (interface{}, bool) {
+	if rp.position < len(rp.buffer) {
+		item := rp.buffer[rp.position]
+		rp.position++
+		return item, true
+	}
+
+	item, ok := rp.input.Next()
+	if !ok {
+		return nil, false
+	}
+	if rp.marked && !rp.overflowed {
+		rp.record(item)
+	}
+	return item, true
+}
+
+// Mark sets the position Reset rewinds to.
+func (rp *Replayable) Mark() {
+	if rewinder, ok := rp.input.(Rewinder); ok {
+		rewinder.Mark()
+		rp.marked = true
+		return
+	}
+
+	rp.marked = true
+	rp.overflowed = false
+	rp.buffer = append([]interface{}(nil), rp.buffer[rp.position:]...)
+	rp.position = 0
+	rp.bytes = 0
+	for _, item := range rp.buffer {
+		rp.bytes += rp.options.SizeFn(item)
+	}
+}
+
+// Reset rewinds to the last mark. It fails with ErrReplayLimit if more
+// elements were read since the mark than the options allow.
+func (rp *Replayable) Reset() error {
+	if !rp.marked {
+		return ErrNoMark
+	}
+	if rewinder, ok := rp.input.(Rewinder); ok {
+		return rewinder.Reset()
+	}
+	if rp.overflowed {
+		return ErrReplayLimit
+	}
+	rp.position = 0
+	return nil
+}
+
+func (rp *Replayable) record(item interface{}) {
+	rp.buffer = append(rp.buffer, item)
+	rp.bytes += rp.options.SizeFn(item)
+	rp.position = len(rp.buffer)
+
+	if (rp.options.MaxItems > 0 && len(rp.buffer) > rp.options.MaxItems) ||
+		(rp.options.MaxBytes > 0 && rp.bytes > rp.options.MaxBytes) {
+		rp.overflowed = true
+		rp.buffer = nil
+		rp.position = 0
+		rp.bytes = 0
+	}
+}
+
+func defaultItemSize(item interface{}) int {
+	switch item := item.(type) {
+	case string:
+		return len(item)
+	case []byte:
+		return len(item)
+	}
+	return 16
+}
@@ -0,0 +1,101 @@
+package streamer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func nextN(iterator streamer.Iterator, n int) []interface{} {
+	var res []interface{}
+	for i := 0; i < n; i++ {
+		item, ok := iterator.Next()
+		if !ok {
+			break
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func Test_replayable(t *testing.T) {
+	t.Run("rewinds a slice iterator natively", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			iterator = streamer.NewSliceIterator([]interface{}{1, 2, 3, 4})
+		)
+
+		assert.Equal([]interface{}{1}, nextN(iterator, 1))
+		iterator.Mark()
+		assert.Equal([]interface{}{2, 3}, nextN(iterator, 2))
+		assert.NoError(iterator.Reset())
+		assert.Equal([]interface{}{2, 3, 4}, collect(iterator))
+	})
+
+	t.Run("replays a stream since the mark", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			source     = streamer.Range(1, 7, 1).Map(func(x interface{}) interface{} { return x })
+			replayable = streamer.NewReplayable(source, streamer.ReplayOptions{})
+		)
+
+		assert.Equal(streamer.ErrNoMark, replayable.Reset())
+
+		assert.Equal([]interface{}{1}, nextN(replayable, 1))
+		replayable.Mark()
+		assert.Equal([]interface{}{2, 3}, nextN(replayable, 2))
+
+		assert.NoError(replayable.Reset())
+		assert.Equal([]interface{}{2}, nextN(replayable, 1))
+
+		replayable.Mark()
+		assert.Equal([]interface{}{3, 4}, nextN(replayable, 2))
+
+		assert.NoError(replayable.Reset())
+		assert.Equal([]interface{}{3, 4, 5, 6}, collect(replayable))
+
+		assert.NoError(replayable.Reset())
+		assert.Equal([]interface{}{3, 4, 5, 6}, collect(replayable))
+	})
+
+	t.Run("fails to reset beyond the item limit", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			source     = streamer.Range(0, 10, 1).Filter(func(x interface{}) bool { return true })
+			replayable = streamer.NewReplayable(source, streamer.ReplayOptions{MaxItems: 2})
+		)
+
+		replayable.Mark()
+		nextN(replayable, 2)
+		assert.NoError(replayable.Reset())
+
+		nextN(replayable, 3)
+		assert.Equal(streamer.ErrReplayLimit, replayable.Reset())
+
+		replayable.Mark()
+		assert.Equal([]interface{}{3}, nextN(replayable, 1))
+		assert.NoError(replayable.Reset())
+		assert.Equal([]interface{}{3}, nextN(replayable, 1))
+	})
+
+	t.Run("fails to reset beyond the byte limit", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			source     = streamer.Lines(strings.NewReader("aaaa\nbbbb\ncccc"))
+			replayable = streamer.NewReplayable(source, streamer.ReplayOptions{MaxBytes: 8})
+		)
+
+		replayable.Mark()
+		assert.Equal([]interface{}{"aaaa", "bbbb"}, nextN(replayable, 2))
+		assert.NoError(replayable.Reset())
+		assert.Equal([]interface{}{"aaaa", "bbbb", "cccc"}, collect(replayable))
+		assert.Equal(streamer.ErrReplayLimit, replayable.Reset())
+	})
+}
@@ -0,0 +1,155 @@
+package streamer
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+// RowsIterator scans each row of a *sql.Rows. If newFn is set, it must return
+// a pointer to a new struct, and rows are scanned into it: columns are matched
+// to fields by their db tag or, if they have none, case-insensitively by their
+// name, and unmatched columns are dropped. Otherwise rows are scanned into
+// map[string]interface{}.
+//
+// The rows are closed when they are exhausted, when scanning fails, or when
+// the iterator is closed.
+type RowsIterator struct {
+	rows  *sql.Rows
+	newFn func() interface{}
+
+	columns []string
+	closed  bool
+	err     error
+}
+
+func NewRowsIterator(rows *sql.Rows, newFn func() interface{}) *RowsIterator {
+	res := &RowsIterator{
+		rows:  rows,
+		newFn: newFn,
+	}
+	return res
+}
+
+func (ri *RowsIterator) Next() (interface{}, bool) {
+	if ri.closed {
+		return nil, false
+	}
+
+	if !ri.rows.Next() {
+		ri.fail(ri.rows.Err())
+		return nil, false
+	}
+
+	if ri.columns == nil {
+		columns, err := ri.rows.Columns()
+		if err != nil {
+			ri.fail(err)
+			return nil, false
+		}
+		ri.columns = columns
+	}
+
+	var (
+		item interface{}
+		err  error
+	)
+	if ri.newFn == nil {
+		item, err = ri.scanMap()
+	} else {
+		item, err = ri.scanStruct()
+	}
+	if err != nil {
+		ri.fail(err)
+		return nil, false
+	}
+	return item, true
+}
+
+func (ri *RowsIterator) Err() error { return ri.err }
+
+func (ri *RowsIterator) Close() error {
+	if ri.closed {
+		return nil
+	}
+	ri.closed = true
+	return ri.rows.Close()
+}
+
+func (ri *RowsIterator) fail(err error) {
+	if ri.err == nil {
+		ri.err = err
+	}
+	if closeErr := ri.Close(); ri.err == nil {
+		ri.err = closeErr
+	}
+}
+
+func (ri *RowsIterator) scanMap() (interface{}, error) {
+	var (
+		values  = make([]interface{}, len(ri.columns))
+		targets = make([]interface{}, len(ri.columns))
+	)
+	for i := range values {
+		targets[i] = &values[i]
+	}
+
+	if err := ri.rows.Scan(targets...); err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]interface{}, len(ri.columns))
+	for i, column := range ri.columns {
+		// drivers may reuse the memory of []byte values between rows
+		if b, ok := values[i].([]byte); ok {
+			values[i] = append([]byte(nil), b...)
+		}
+		item[column] = values[i]
+	}
+	return item, nil
+}
+
+func (ri *RowsIterator) scanStruct() (interface{}, error) {
+	var (
+		target  = ri.newFn()
+		value   = reflect.ValueOf(target).Elem()
+		targets = make([]interface{}, len(ri.columns))
+	)
+
+	for i, column := range ri.columns {
+		field, ok := rowsField(value, column)
+		if !ok {
+			targets[i] = new(interface{})
+			continue
+		}
+		targets[i] = field.Addr().Interface()
+	}
+
+	if err := ri.rows.Scan(targets...); err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+func rowsField(value reflect.Value, column string) (reflect.Value, bool) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("db")
+		if name == "-" {
+			continue
+		}
+		if name == column || (name == "" && strings.EqualFold(field.Name, column)) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Rows returns a stream of the rows of a query result.
+func Rows(rows *sql.Rows, newFn func() interface{}) *Stream {
+	return NewStream(NewRowsIterator(rows, newFn))
+}
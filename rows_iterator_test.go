@@ -0,0 +1,217 @@
+package streamer_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+// fakeDriver serves a fixed result for every query and records how many
+// result sets are still open.
+type fakeDriver struct {
+	mu      sync.Mutex
+	open    int
+	columns []string
+	values  [][]driver.Value
+	rowsErr error
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (d *fakeDriver) openRows() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.open
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{c.d}, nil }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.open++
+	return &fakeRows{d: s.d}, nil
+}
+
+type fakeRows struct {
+	d     *fakeDriver
+	index int
+}
+
+func (r *fakeRows) Columns() []string { return r.d.columns }
+
+func (r *fakeRows) Close() error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+	r.d.open--
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.index == len(r.d.values) {
+		if r.d.rowsErr != nil {
+			return r.d.rowsErr
+		}
+		return io.EOF
+	}
+	copy(dest, r.d.values[r.index])
+	r.index++
+	return nil
+}
+
+var fakeDriverCount int
+
+func openFakeDB(t *testing.T, d *fakeDriver) *sql.DB {
+	fakeDriverCount++
+	name := fmt.Sprintf("streamer-fake-%d", fakeDriverCount)
+	sql.Register(name, d)
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+type user struct {
+	ID    int64 `db:"id"`
+	Name  string
+	Email string `db:"-"`
+}
+
+func Test_rows_iterator(t *testing.T) {
+	newDriver := func() *fakeDriver {
+		return &fakeDriver{
+			columns: []string{"id", "name", "extra"},
+			values: [][]driver.Value{
+				{int64(1), "alice", []byte("x")},
+				{int64(2), "bob", nil},
+				{int64(3), "carol", []byte("z")},
+			},
+		}
+	}
+
+	t.Run("scans rows into maps", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			d  = newDriver()
+			db = openFakeDB(t, d)
+		)
+		defer db.Close()
+
+		rows, err := db.Query("select")
+		assert.NoError(err)
+
+		stream := streamer.Rows(rows, nil)
+		items := collect(stream)
+
+		assert.NoError(stream.Err())
+		assert.Len(items, 3)
+		assert.Equal(map[string]interface{}{"id": int64(1), "name": "alice", "extra": []byte("x")}, items[0])
+		assert.Equal(0, d.openRows())
+	})
+
+	t.Run("scans rows into structs", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			d  = newDriver()
+			db = openFakeDB(t, d)
+		)
+		defer db.Close()
+
+		rows, err := db.Query("select")
+		assert.NoError(err)
+
+		stream := streamer.Rows(rows, func() interface{} { return new(user) }).
+			Filter(func(x interface{}) bool { return x.(user).ID != 2 })
+
+		assert.Equal([]interface{}{user{ID: 1, Name: "alice"}, user{ID: 3, Name: "carol"}}, collect(stream))
+		assert.NoError(stream.Err())
+		assert.Equal(0, d.openRows())
+	})
+
+	t.Run("closes abandoned rows", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			d  = newDriver()
+			db = openFakeDB(t, d)
+		)
+		defer db.Close()
+
+		rows, err := db.Query("select")
+		assert.NoError(err)
+
+		stream := streamer.Rows(rows, nil).Take(1)
+		assert.Len(collect(stream), 1)
+		assert.Equal(1, d.openRows())
+
+		assert.NoError(stream.Close())
+		assert.Equal(0, d.openRows())
+	})
+
+	t.Run("surfaces rows errors", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			rowsErr = errors.New("connection lost")
+			d       = newDriver()
+		)
+
+		d.rowsErr = rowsErr
+		db := openFakeDB(t, d)
+		defer db.Close()
+
+		rows, err := db.Query("select")
+		assert.NoError(err)
+
+		stream := streamer.Rows(rows, nil)
+
+		assert.Len(collect(stream), 3)
+		assert.Equal(rowsErr, stream.Err())
+		assert.Equal(0, d.openRows())
+	})
+
+	t.Run("surfaces scan errors", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			d  = newDriver()
+			db = openFakeDB(t, d)
+		)
+		defer db.Close()
+
+		d.values[1][0] = "not a number"
+
+		rows, err := db.Query("select")
+		assert.NoError(err)
+
+		stream := streamer.Rows(rows, func() interface{} { return new(user) })
+
+		assert.Len(collect(stream), 1)
+		assert.Error(stream.Err())
+		assert.Equal(0, d.openRows())
+	})
+}
@@ -1,14 +1,18 @@
 package streamer
 
+import "fmt"
+
 type SliceIterator struct {
 	input   []interface{}
 	current int
+	mark    int
 }
 
 func NewSliceIterator(input []interface{}) *SliceIterator {
 	res := &SliceIterator{
 		input:   input,
 		current: -1,
+		mark:    -1,
 	}
 
 	return res
@@ -21,3 +25,27 @@ func (it *SliceIterator) Next() (interface{}, bool) {
 	}
 	return nil, false
 }
+
+// Mark records the current position, to rewind to with Reset.
+func (it *SliceIterator) Mark() { it.mark = it.current }
+
+// Reset rewinds to the last mark, or to the start if there is none.
+func (it *SliceIterator) Reset() error {
+	it.current = it.mark
+	return nil
+}
+
+func (it *SliceIterator) Snapshot() (*State, error) {
+	return &State{Kind: "slice", Offset: int64(it.current + 1)}, nil
+}
+
+func (it *SliceIterator) Restore(state *State) error {
+	if err := checkState(state, "slice"); err != nil {
+		return err
+	}
+	if state.Offset < 0 || state.Offset > int64(len(it.input)) {
+		return fmt.Errorf("streamer: slice offset %d out of range", state.Offset)
+	}
+	it.current = int(state.Offset) - 1
+	return nil
+}
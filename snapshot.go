@@ -0,0 +1,76 @@
+package streamer
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotSnapshottable = errors.New("streamer: iterator does not support snapshots")
+
+// State is the serializable position of an iterator, nesting the state of its
+// input. It can be stored, e.g. as JSON, to resume a pipeline later.
+type State struct {
+	Kind   string `json:"kind"`
+	Offset int64  `json:"offset,omitempty"`
+	Count  int    `json:"count,omitempty"`
+	Input  *State `json:"input,omitempty"`
+}
+
+// Snapshotter is implemented by iterators whose position can be checkpointed
+// and restored. Restore must be called on an iterator built the same way, over
+// the same source, as the one the state was taken from.
+type Snapshotter interface {
+	Snapshot() (*State, error)
+	Restore(*State) error
+}
+
+func snapshotOf(it Iterator) (*State, error) {
+	snapshotter, ok := it.(Snapshotter)
+	if !ok {
+		return nil, ErrNotSnapshottable
+	}
+	return snapshotter.Snapshot()
+}
+
+func restoreTo(it Iterator, state *State) error {
+	snapshotter, ok := it.(Snapshotter)
+	if !ok {
+		return ErrNotSnapshottable
+	}
+	return snapshotter.Restore(state)
+}
+
+// checkState verifies that state was taken from an iterator of the given kind.
+func checkState(state *State, kind string) error {
+	if state == nil || state.Kind != kind {
+		return fmt.Errorf("streamer: can not restore %v state into a %v iterator", stateKind(state), kind)
+	}
+	return nil
+}
+
+func stateKind(state *State) string {
+	if state == nil {
+		return "nil"
+	}
+	return state.Kind
+}
+
+// stageSnapshot takes the state of a stage and its input.
+func stageSnapshot(kind string, count int, input Iterator) (*State, error) {
+	inputState, err := snapshotOf(input)
+	if err != nil {
+		return nil, err
+	}
+	return &State{Kind: kind, Count: count, Input: inputState}, nil
+}
+
+// restoreStage restores the input of a stage and returns the stage's count.
+func restoreStage(kind string, state *State, input Iterator) (int, error) {
+	if err := checkState(state, kind); err != nil {
+		return 0, err
+	}
+	if err := restoreTo(input, state.Input); err != nil {
+		return 0, err
+	}
+	return state.Count, nil
+}
@@ -0,0 +1,172 @@
+package streamer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_snapshot(t *testing.T) {
+	t.Run("resumes a pipeline from a serialized state", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input []interface{}
+		)
+
+		for i := 0; i < 20; i++ {
+			input = append(input, i)
+		}
+
+		newPipeline := func() *streamer.Stream {
+			return streamer.NewStream(streamer.NewSliceIterator(input)).
+				Map(func(x interface{}) interface{} { return x.(int) * 10 }).
+				Filter(func(x interface{}) bool { return x.(int)%20 == 0 }).
+				Skip(1).
+				Take(6).
+				ChunkEvery(2)
+		}
+
+		original := newPipeline()
+		assert.Equal([]interface{}{[]interface{}{20, 40}}, nextN(original, 1))
+
+		state, err := original.Snapshot()
+		assert.NoError(err)
+
+		data, err := json.Marshal(state)
+		assert.NoError(err)
+
+		var restoredState streamer.State
+		assert.NoError(json.Unmarshal(data, &restoredState))
+
+		resumed := newPipeline()
+		assert.NoError(resumed.Restore(&restoredState))
+
+		expectedOutput := []interface{}{[]interface{}{60, 80}, []interface{}{100, 120}}
+		assert.Equal(expectedOutput, collect(resumed))
+		assert.Equal(expectedOutput, collect(original))
+	})
+
+	t.Run("resumes a line source", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n"
+		)
+
+		original := streamer.JSONLines(strings.NewReader(input), nil).Take(3)
+		assert.Len(nextN(original, 1), 1)
+
+		state, err := original.Snapshot()
+		assert.NoError(err)
+
+		resumed := streamer.JSONLines(strings.NewReader(input), nil).Take(3)
+		assert.NoError(resumed.Restore(state))
+
+		expectedOutput := []interface{}{
+			map[string]interface{}{"id": 2.0},
+			map[string]interface{}{"id": 3.0},
+		}
+		assert.Equal(expectedOutput, collect(resumed))
+	})
+
+	t.Run("resumes a reader at a byte offset", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = "alpha beta\ngamma"
+		)
+
+		original := streamer.Lines(strings.NewReader(input))
+		nextN(original, 1)
+
+		state, err := original.Snapshot()
+		assert.NoError(err)
+		assert.Equal(int64(11), state.Offset)
+
+		resumed := streamer.Lines(strings.NewReader(input))
+		assert.NoError(resumed.Restore(state))
+		assert.Equal([]interface{}{"gamma"}, collect(resumed))
+	})
+
+	t.Run("resumes a csv source", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input   = "a,b\n1,\"two\nlines\"\n3,4\n5,6\n"
+			options = streamer.CSVOptions{Header: true}
+		)
+
+		original := streamer.CSV(strings.NewReader(input), options)
+		assert.Len(nextN(original, 1), 1)
+
+		state, err := original.Snapshot()
+		assert.NoError(err)
+
+		resumed := streamer.CSV(strings.NewReader(input), options)
+		assert.NoError(resumed.Restore(state))
+
+		expectedOutput := []interface{}{
+			map[string]string{"a": "3", "b": "4"},
+			map[string]string{"a": "5", "b": "6"},
+		}
+		assert.Equal(expectedOutput, collect(resumed))
+		assert.NoError(resumed.Err())
+	})
+
+	t.Run("resumes the positions of indexed stages", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []interface{}{"a", "b", "c", "d", "e"}
+		)
+
+		newPipeline := func() *streamer.Stream {
+			return streamer.NewStream(streamer.NewSliceIterator(input)).
+				Enumerate().
+				Values().
+				FilterIndexed(func(index int, x interface{}) bool { return index != 3 }).
+				MapIndexed(func(index int, x interface{}) interface{} { return fmt.Sprint(index, x) }).
+				LogEvery(slog.New(slog.NewTextHandler(ioutil.Discard, nil)), "seen", 2)
+		}
+
+		original := newPipeline()
+		assert.Equal([]interface{}{"0a", "1b"}, nextN(original, 2))
+
+		state, err := original.Snapshot()
+		assert.NoError(err)
+		assert.Equal(2, state.Count)
+		assert.Equal(2, state.Input.Count)
+
+		resumed := newPipeline()
+		assert.NoError(resumed.Restore(state))
+
+		assert.Equal([]interface{}{"2c", "3e"}, collect(resumed))
+	})
+
+	t.Run("fails for stages without snapshots", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{1})).CycleN(2)
+
+		_, err := stream.Snapshot()
+		assert.Equal(streamer.ErrNotSnapshottable, err)
+		assert.Equal(streamer.ErrNotSnapshottable, stream.Restore(&streamer.State{}))
+	})
+
+	t.Run("fails for a state of another pipeline", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]interface{}{1})).Take(1)
+
+		assert.Error(stream.Restore(&streamer.State{Kind: "skip", Input: &streamer.State{Kind: "slice"}}))
+		assert.Error(stream.Restore(&streamer.State{Kind: "take", Input: &streamer.State{Kind: "slice", Offset: 5}}))
+	})
+}
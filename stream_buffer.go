@@ -0,0 +1,118 @@
+package streamer
+
+import (
+	"runtime"
+	"sync"
+)
+
+type bufferResult struct {
+	item     interface{}
+	panicked bool
+	panicVal interface{}
+}
+
+// bufferProducer is the part of a buffer stage shared with its goroutine; it
+// does not reference the bufferStream, so an abandoned stream can be collected
+// and its finalizer can stop the goroutine.
+type bufferProducer struct {
+	input    Iterator
+	upstream *detachedUpstream
+	results  chan bufferResult
+	stop     chan struct{}
+	done     chan struct{}
+
+	stopOnce sync.Once
+}
+
+func (bp *bufferProducer) run() {
+	defer close(bp.done)
+	defer close(bp.results)
+	defer bp.upstream.finish()
+
+	for {
+		res, ok := bp.next()
+		if !ok && !res.panicked {
+			return
+		}
+		select {
+		case bp.results <- res:
+		case <-bp.stop:
+			return
+		}
+		if res.panicked {
+			return
+		}
+	}
+}
+
+func (bp *bufferProducer) next() (res bufferResult, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			res.panicked, res.panicVal = true, r
+		}
+	}()
+	res.item, ok = bp.input.Next()
+	return
+}
+
+func (bp *bufferProducer) halt() {
+	bp.stopOnce.Do(func() { close(bp.stop) })
+}
+
+type bufferStream struct {
+	producer *bufferProducer
+
+	started bool
+	done    bool
+}
+
+func newBufferStream(input Iterator, size int, upstream *Stream) (res *bufferStream) {
+	if size < 0 {
+		size = 0
+	}
+	res = &bufferStream{
+		producer: &bufferProducer{
+			input:    input,
+			upstream: &detachedUpstream{chain: upstream},
+			results:  make(chan bufferResult, size),
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		},
+	}
+	runtime.SetFinalizer(res, func(bs *bufferStream) { bs.producer.halt() })
+	return
+}
+
+func (bs *bufferStream) Next() (interface{}, bool) {
+	if bs.done {
+		return nil, false
+	}
+	if !bs.started {
+		bs.started = true
+		go bs.producer.run()
+	}
+
+	res, ok := <-bs.producer.results
+	if !ok {
+		bs.done = true
+		return nil, false
+	}
+	if res.panicked {
+		bs.done = true
+		<-bs.producer.done
+		panic(res.panicVal)
+	}
+	return res.item, true
+}
+
+func (bs *bufferStream) Err() error { return bs.producer.upstream.Err(bs.started) }
+
+// Close stops the goroutine without waiting for the Next call of the input it
+// may be running; the goroutine closes the input once that call returns.
+func (bs *bufferStream) Close() error {
+	bs.done = true
+	bs.producer.halt()
+	return bs.producer.upstream.close(bs.started)
+}
+
+func (bs *bufferStream) pullsConcurrently() {}
@@ -4,8 +4,11 @@ type chunkByStream struct {
 	input   Iterator
 	chunkFn func(x interface{}) interface{}
 
-	leftoverChunkItem interface{}
-	lastFlag          interface{}
+	// chunk is the chunk being built, with the flag of its elements; it is
+	// kept across calls, so a panic of chunkFn with PanicSkip drops only the
+	// element that caused it
+	chunk []interface{}
+	flag  interface{}
 }
 
 func newChunkByStream(input Iterator, chunkFn func(x interface{}) interface{}) (res *chunkByStream) {
@@ -17,45 +20,24 @@ func newChunkByStream(input Iterator, chunkFn func(x interface{}) interface{}) (
 }
 
 func (cs *chunkByStream) Next() (interface{}, bool) {
-	var (
-		flag           interface{}
-		flagCalculated bool
-		chunk          []interface{}
-	)
-
-	if cs.lastFlag != nil {
-		flag = cs.lastFlag
-		flagCalculated = true
-		cs.lastFlag = nil
-	}
-
-	if cs.leftoverChunkItem != nil {
-		chunk = append(chunk, cs.leftoverChunkItem)
-		cs.leftoverChunkItem = nil
-	}
-
 	for item, ok := cs.input.Next(); ok; item, ok = cs.input.Next() {
-		current := item
-		cond := cs.chunkFn(current)
-
-		if !flagCalculated {
-			flag = cond
-			flagCalculated = true
+		cond := cs.chunkFn(item)
+		if len(cs.chunk) == 0 {
+			cs.chunk, cs.flag = []interface{}{item}, cond
+			continue
 		}
-
-		if flag != cond {
-			cs.leftoverChunkItem = current
-			cs.lastFlag = cond
-			break
+		if cond != cs.flag {
+			chunk := cs.chunk
+			cs.chunk, cs.flag = []interface{}{item}, cond
+			return chunk, true
 		}
-
-		flag = cond
-		chunk = append(chunk, current)
+		cs.chunk = append(cs.chunk, item)
 	}
 
-	if len(chunk) == 0 {
+	if len(cs.chunk) == 0 {
 		return nil, false
 	}
-
+	chunk := cs.chunk
+	cs.chunk, cs.flag = nil, nil
 	return chunk, true
 }
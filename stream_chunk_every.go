@@ -31,3 +31,12 @@ func (ce *chunkEveryStream) Next() (interface{}, bool) {
 
 	return chunk, true
 }
+
+func (ce *chunkEveryStream) Snapshot() (*State, error) {
+	return stageSnapshot("chunk-every", 0, ce.input)
+}
+
+func (ce *chunkEveryStream) Restore(state *State) error {
+	_, err := restoreStage("chunk-every", state, ce.input)
+	return err
+}
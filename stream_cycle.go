@@ -0,0 +1,63 @@
+package streamer
+
+type cycleStream struct {
+	input Iterator
+	// passes is the number of passes left; negative means forever.
+	passes int
+
+	buffer    []interface{}
+	replaying bool
+	position  int
+}
+
+func newCycleStream(input Iterator, passes int) (res *cycleStream) {
+	res = &cycleStream{
+		input:  input,
+		passes: passes,
+	}
+
+	// the remaining part of a slice iterator is replayed without copying it
+	if si, ok := input.(*SliceIterator); ok {
+		res.buffer = si.input[si.current+1:]
+		res.replaying = true
+	}
+	return
+}
+
+func (cs *cycleStream) Next() (interface{}, bool) {
+	if cs.passes == 0 {
+		return nil, false
+	}
+
+	if !cs.replaying {
+		item, ok := cs.input.Next()
+		if ok {
+			cs.buffer = append(cs.buffer, item)
+			return item, true
+		}
+		cs.replaying = true
+		cs.endPass()
+	}
+
+	if len(cs.buffer) == 0 {
+		return nil, false
+	}
+
+	if cs.position == len(cs.buffer) {
+		cs.position = 0
+		cs.endPass()
+	}
+	if cs.passes == 0 {
+		return nil, false
+	}
+
+	item := cs.buffer[cs.position]
+	cs.position++
+	return item, true
+}
+
+func (cs *cycleStream) endPass() {
+	if cs.passes > 0 {
+		cs.passes--
+	}
+}
@@ -0,0 +1,36 @@
+package streamer
+
+type enumerateStream struct {
+	input Iterator
+	index int
+}
+
+func newEnumerateStream(input Iterator) (res *enumerateStream) {
+	res = &enumerateStream{
+		input: input,
+	}
+	return
+}
+
+func (es *enumerateStream) Next() (interface{}, bool) {
+	item, ok := es.input.Next()
+	if !ok {
+		return nil, false
+	}
+	res := Pair{Key: es.index, Value: item}
+	es.index++
+	return res, true
+}
+
+func (es *enumerateStream) Snapshot() (*State, error) {
+	return stageSnapshot("enumerate", es.index, es.input)
+}
+
+func (es *enumerateStream) Restore(state *State) error {
+	index, err := restoreStage("enumerate", state, es.input)
+	if err != nil {
+		return err
+	}
+	es.index = index
+	return nil
+}
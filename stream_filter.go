@@ -31,3 +31,10 @@ func (fs *filterStream) Next() (interface{}, bool) {
 
 	return nil, false
 }
+
+func (fs *filterStream) Snapshot() (*State, error) { return stageSnapshot("filter", 0, fs.input) }
+
+func (fs *filterStream) Restore(state *State) error {
+	_, err := restoreStage("filter", state, fs.input)
+	return err
+}
@@ -0,0 +1,67 @@
+package streamer
+
+// indexedStream calls indexedFn with each element and its zero-based
+// position, keeping its result if keep is true. If enumerated, the elements
+// are the Pairs of an upstream Enumerate stage, and indexedFn receives their
+// keys and values instead; keepsElements passes the kept elements on as they
+// are, so the positions reach later stages.
+type indexedStream struct {
+	input         Iterator
+	indexedFn     func(index int, x interface{}) (res interface{}, keep bool)
+	enumerated    bool
+	keepsElements bool
+
+	index int
+}
+
+func newIndexedStream(input Iterator, indexedFn func(index int, x interface{}) (interface{}, bool), enumerated, keepsElements bool) (res *indexedStream) {
+	res = &indexedStream{
+		input:         input,
+		indexedFn:     indexedFn,
+		enumerated:    enumerated,
+		keepsElements: keepsElements,
+	}
+	return
+}
+
+func (is *indexedStream) Next() (interface{}, bool) {
+	for item, ok := is.input.Next(); ok; item, ok = is.input.Next() {
+		index, x := is.position(item)
+		res, keep := is.indexedFn(index, x)
+		if !keep {
+			continue
+		}
+		if is.keepsElements {
+			return item, true
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+func (is *indexedStream) position(item interface{}) (int, interface{}) {
+	index := is.index
+	is.index++
+
+	if is.enumerated {
+		if pair, ok := item.(Pair); ok {
+			if key, ok := pair.Key.(int); ok {
+				return key, pair.Value
+			}
+		}
+	}
+	return index, item
+}
+
+func (is *indexedStream) Snapshot() (*State, error) {
+	return stageSnapshot("indexed", is.index, is.input)
+}
+
+func (is *indexedStream) Restore(state *State) error {
+	index, err := restoreStage("indexed", state, is.input)
+	if err != nil {
+		return err
+	}
+	is.index = index
+	return nil
+}
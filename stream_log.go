@@ -0,0 +1,50 @@
+package streamer
+
+import "log/slog"
+
+type logStream struct {
+	input  Iterator
+	logger *slog.Logger
+	label  string
+	n      int
+
+	count int
+}
+
+func newLogStream(input Iterator, logger *slog.Logger, label string, n int) (res *logStream) {
+	res = &logStream{
+		input:  input,
+		logger: logger,
+		label:  label,
+		n:      n,
+	}
+	return
+}
+
+func (ls *logStream) Next() (interface{}, bool) {
+	item, ok := ls.input.Next()
+	if !ok {
+		return nil, false
+	}
+
+	ls.count++
+	switch {
+	case ls.count%ls.n != 0:
+	case ls.n == 1:
+		ls.logger.Info(ls.label, "index", ls.count-1, "element", item)
+	default:
+		ls.logger.Info(ls.label, "count", ls.count, "element", item)
+	}
+	return item, true
+}
+
+func (ls *logStream) Snapshot() (*State, error) { return stageSnapshot("log", ls.count, ls.input) }
+
+func (ls *logStream) Restore(state *State) error {
+	count, err := restoreStage("log", state, ls.input)
+	if err != nil {
+		return err
+	}
+	ls.count = count
+	return nil
+}
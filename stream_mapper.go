@@ -20,3 +20,10 @@ func (ms *mapperStream) Next() (interface{}, bool) {
 	}
 	return ms.mapFn(next), true
 }
+
+func (ms *mapperStream) Snapshot() (*State, error) { return stageSnapshot("map", 0, ms.input) }
+
+func (ms *mapperStream) Restore(state *State) error {
+	_, err := restoreStage("map", state, ms.input)
+	return err
+}
@@ -0,0 +1,183 @@
+package streamer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+var ErrEmptyStream = errors.New("streamer: empty stream")
+
+// NonNumericError is reported by numeric terminals for an element that is
+// not of an integer or floating point type.
+type NonNumericError struct {
+	Item interface{}
+}
+
+func (e *NonNumericError) Error() string {
+	return fmt.Sprintf("streamer: non-numeric element %v (%T)", e.Item, e.Item)
+}
+
+// Stats holds one-pass statistics of a numeric stream. Variance is the
+// population variance.
+type Stats struct {
+	Count    int
+	Sum      float64
+	Min      float64
+	Max      float64
+	Mean     float64
+	Variance float64
+	StdDev   float64
+}
+
+func toFloat64(item interface{}) (float64, error) {
+	v := reflect.ValueOf(item)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, &NonNumericError{Item: item}
+}
+
+// sumOf adds integer elements exactly, and converts their sum to float64
+// once, at the end.
+func sumOf(st *Stream) (float64, error) {
+	var (
+		intSum   big.Int
+		floatSum float64
+		term     big.Int
+	)
+
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		v := reflect.ValueOf(item)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			intSum.Add(&intSum, term.SetInt64(v.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			intSum.Add(&intSum, term.SetUint64(v.Uint()))
+		case reflect.Float32, reflect.Float64:
+			floatSum += v.Float()
+		default:
+			return 0, &NonNumericError{Item: item}
+		}
+	}
+
+	sum, _ := new(big.Float).SetInt(&intSum).Float64()
+	return sum + floatSum, st.Err()
+}
+
+func averageOf(st *Stream) (float64, error) {
+	stats, err := statsOf(st)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Mean, nil
+}
+
+// extremeBy returns the element whose key wins against all others; on ties
+// the first one is kept.
+func extremeBy(st *Stream, keyFn func(interface{}) interface{}, wins func(a, b float64) bool) (interface{}, error) {
+	var (
+		best    interface{}
+		bestKey float64
+		found   bool
+	)
+
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		key, err := toFloat64(keyFn(item))
+		if err != nil {
+			return nil, err
+		}
+		if !found || wins(key, bestKey) {
+			best, bestKey, found = item, key, true
+		}
+	}
+
+	if err := st.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrEmptyStream
+	}
+	return best, nil
+}
+
+func statsOf(st *Stream) (Stats, error) {
+	var (
+		stats Stats
+		m2    float64
+	)
+
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		v, err := toFloat64(item)
+		if err != nil {
+			return Stats{}, err
+		}
+
+		if stats.Count == 0 || v < stats.Min {
+			stats.Min = v
+		}
+		if stats.Count == 0 || v > stats.Max {
+			stats.Max = v
+		}
+
+		stats.Count++
+		stats.Sum += v
+		delta := v - stats.Mean
+		stats.Mean += delta / float64(stats.Count)
+		m2 += delta * (v - stats.Mean)
+	}
+
+	if err := st.Err(); err != nil {
+		return Stats{}, err
+	}
+	if stats.Count == 0 {
+		return Stats{}, ErrEmptyStream
+	}
+
+	stats.Variance = m2 / float64(stats.Count)
+	stats.StdDev = math.Sqrt(stats.Variance)
+	return stats, nil
+}
+
+func identity(x interface{}) interface{} { return x }
+
+func lessFloat64(a, b float64) bool { return a < b }
+
+func greaterFloat64(a, b float64) bool { return a > b }
+
+func quantileSketchOf(st *Stream, compression float64) (*TDigest, error) {
+	digest := NewTDigest(compression)
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		v, err := toFloat64(item)
+		if err != nil {
+			return nil, err
+		}
+		digest.Add(v)
+	}
+	if err := st.Err(); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+func histogramOf(st *Stream, bounds []float64) (*Histogram, error) {
+	histogram := NewHistogram(bounds...)
+	for item, ok := st.Next(); ok; item, ok = st.Next() {
+		v, err := toFloat64(item)
+		if err != nil {
+			return nil, err
+		}
+		histogram.Add(v)
+	}
+	if err := st.Err(); err != nil {
+		return nil, err
+	}
+	return histogram, nil
+}
@@ -0,0 +1,49 @@
+package streamer
+
+// pairStream applies pairFn to a stream of Pair elements, keeping its result
+// if keep is true. The stream ends with a NotPairError on any other element.
+type pairStream struct {
+	input  Iterator
+	pairFn func(pair Pair) (res interface{}, keep bool)
+
+	err error
+}
+
+func newPairStream(input Iterator, pairFn func(pair Pair) (interface{}, bool)) (res *pairStream) {
+	res = &pairStream{
+		input:  input,
+		pairFn: pairFn,
+	}
+	return
+}
+
+func (ps *pairStream) Next() (interface{}, bool) {
+	if ps.err != nil {
+		return nil, false
+	}
+
+	for item, ok := ps.input.Next(); ok; item, ok = ps.input.Next() {
+		pair, isPair := item.(Pair)
+		if !isPair {
+			ps.err = &NotPairError{Item: item}
+			return nil, false
+		}
+		if res, keep := ps.pairFn(pair); keep {
+			return res, true
+		}
+	}
+
+	return nil, false
+}
+
+func (ps *pairStream) Err() error { return ps.err }
+
+func (ps *pairStream) Snapshot() (*State, error) { return stageSnapshot("pair", 0, ps.input) }
+
+func (ps *pairStream) Restore(state *State) error {
+	if _, err := restoreStage("pair", state, ps.input); err != nil {
+		return err
+	}
+	ps.err = nil
+	return nil
+}
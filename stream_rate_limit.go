@@ -0,0 +1,127 @@
+package streamer
+
+import "time"
+
+// rateLimitStream is a token bucket holding up to limit tokens, refilled at
+// limit tokens per period; each element takes a token.
+type rateLimitStream struct {
+	input  Iterator
+	limit  float64
+	period time.Duration
+	clock  Clock
+
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimitStream(input Iterator, limit int, period time.Duration, clock Clock) (res *rateLimitStream) {
+	res = &rateLimitStream{
+		input:  input,
+		limit:  float64(limit),
+		period: period,
+		clock:  clock,
+		tokens: float64(limit),
+		last:   clock.Now(),
+	}
+	return
+}
+
+func (rl *rateLimitStream) Next() (interface{}, bool) {
+	item, ok := rl.input.Next()
+	if !ok {
+		return nil, false
+	}
+	if rl.period <= 0 {
+		return item, true
+	}
+
+	rl.refill()
+	if rl.tokens < 1 {
+		missing := 1 - rl.tokens
+		<-rl.clock.After(time.Duration(missing * float64(rl.period) / rl.limit))
+		rl.refill()
+	}
+	rl.tokens--
+
+	return item, true
+}
+
+func (rl *rateLimitStream) refill() {
+	now := rl.clock.Now()
+	rl.tokens += float64(now.Sub(rl.last)) / float64(rl.period) * rl.limit
+	if rl.tokens > rl.limit {
+		rl.tokens = rl.limit
+	}
+	rl.last = now
+}
+
+//
+
+type throttleStream struct {
+	input  Iterator
+	window time.Duration
+	clock  Clock
+
+	windowEnd time.Time
+}
+
+func newThrottleStream(input Iterator, window time.Duration, clock Clock) (res *throttleStream) {
+	res = &throttleStream{
+		input:  input,
+		window: window,
+		clock:  clock,
+	}
+	return
+}
+
+func (ts *throttleStream) Next() (interface{}, bool) {
+	for item, ok := ts.input.Next(); ok; item, ok = ts.input.Next() {
+		now := ts.clock.Now()
+		if now.Before(ts.windowEnd) {
+			continue
+		}
+		ts.windowEnd = now.Add(ts.window)
+		return item, true
+	}
+	return nil, false
+}
+
+//
+
+type debounceStream struct {
+	input    Iterator
+	quietFor time.Duration
+	clock    Clock
+
+	pending    interface{}
+	hasPending bool
+	pendingAt  time.Time
+}
+
+func newDebounceStream(input Iterator, quietFor time.Duration, clock Clock) (res *debounceStream) {
+	res = &debounceStream{
+		input:    input,
+		quietFor: quietFor,
+		clock:    clock,
+	}
+	return
+}
+
+func (ds *debounceStream) Next() (interface{}, bool) {
+	for item, ok := ds.input.Next(); ok; item, ok = ds.input.Next() {
+		now := ds.clock.Now()
+		previous, hadPending, previousAt := ds.pending, ds.hasPending, ds.pendingAt
+		ds.pending, ds.hasPending, ds.pendingAt = item, true, now
+
+		if hadPending && now.Sub(previousAt) >= ds.quietFor {
+			return previous, true
+		}
+	}
+
+	if ds.hasPending {
+		item := ds.pending
+		ds.pending, ds.hasPending = nil, false
+		return item, true
+	}
+	return nil, false
+}
@@ -0,0 +1,129 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures MapWithRetry. Zero values default to 3 attempts, a
+// 100ms initial backoff, a multiplier of 2 and no maximum backoff. Jitter,
+// between 0 and 1, randomly shortens each backoff by up to that fraction.
+// Retryable decides which errors are retried; by default all are. Context
+// cancels the waiting between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	Retryable      func(error) bool
+	Context        context.Context
+}
+
+type retryStream struct {
+	input  Iterator
+	mapFn  func(x interface{}) (interface{}, error)
+	policy RetryPolicy
+	clock  Clock
+
+	err error
+}
+
+func newRetryStream(input Iterator, mapFn func(x interface{}) (interface{}, error), policy RetryPolicy, clock Clock) (res *retryStream) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.Multiplier <= 0 {
+		policy.Multiplier = 2
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = func(error) bool { return true }
+	}
+	if policy.Context == nil {
+		policy.Context = context.Background()
+	}
+	res = &retryStream{
+		input:  input,
+		mapFn:  mapFn,
+		policy: policy,
+		clock:  clock,
+	}
+	return
+}
+
+func (rs *retryStream) Next() (interface{}, bool) {
+	if rs.err != nil {
+		return nil, false
+	}
+
+	item, ok := rs.input.Next()
+	if !ok {
+		return nil, false
+	}
+
+	res, err := rs.mapWithRetry(item)
+	if err != nil {
+		rs.err = err
+		return nil, false
+	}
+	return res, true
+}
+
+func (rs *retryStream) Err() error { return rs.err }
+
+func (rs *retryStream) mapWithRetry(item interface{}) (interface{}, error) {
+	backoff := rs.policy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		res, err := rs.mapFn(item)
+		if err == nil {
+			return res, nil
+		}
+		if attempt == rs.policy.MaxAttempts || !rs.policy.Retryable(err) {
+			return nil, fmt.Errorf("streamer: map failed after %d attempts: %w", attempt, err)
+		}
+
+		if err := rs.wait(backoff); err != nil {
+			return nil, err
+		}
+
+		backoff = time.Duration(float64(backoff) * rs.policy.Multiplier)
+		if rs.policy.MaxBackoff > 0 && backoff > rs.policy.MaxBackoff {
+			backoff = rs.policy.MaxBackoff
+		}
+	}
+}
+
+func (rs *retryStream) wait(backoff time.Duration) error {
+	if rs.policy.Jitter > 0 {
+		backoff -= time.Duration(rs.policy.Jitter * rand.Float64() * float64(backoff))
+	}
+
+	timer := rs.clock.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-rs.policy.Context.Done():
+		return rs.policy.Context.Err()
+	}
+}
+
+func (rs *retryStream) Snapshot() (*State, error) {
+	return stageSnapshot("map-with-retry", 0, rs.input)
+}
+
+func (rs *retryStream) Restore(state *State) error {
+	_, err := restoreStage("map-with-retry", state, rs.input)
+	if err != nil {
+		return err
+	}
+	rs.err = nil
+	return nil
+}
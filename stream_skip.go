@@ -23,3 +23,16 @@ func (sc *skipStream) Next() (interface{}, bool) {
 	}
 	return sc.input.Next()
 }
+
+func (sc *skipStream) Snapshot() (*State, error) {
+	return stageSnapshot("skip", sc.skipCount, sc.input)
+}
+
+func (sc *skipStream) Restore(state *State) error {
+	count, err := restoreStage("skip", state, sc.input)
+	if err != nil {
+		return err
+	}
+	sc.skipCount = count
+	return nil
+}
@@ -0,0 +1,262 @@
+package streamer
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+const defaultExternalSortRunSize = 100000
+
+type ExternalSortOptions struct {
+	// RunSize is the number of items sorted in memory before they are
+	// spilled to a temp file. Defaults to 100000.
+	RunSize int
+	// Codec serializes spilled items. Defaults to GobCodec.
+	Codec Codec
+	// TempDir is where runs are spilled. Defaults to os.TempDir().
+	TempDir string
+}
+
+type externalSortStream struct {
+	input   Iterator
+	lessFn  func(a, b interface{}) bool
+	options ExternalSortOptions
+
+	started bool
+	buffer  []interface{}
+	runs    []*sortRun
+	merger  runHeap
+	err     error
+}
+
+func newExternalSortStream(input Iterator, lessFn func(a, b interface{}) bool, options ExternalSortOptions) (res *externalSortStream) {
+	if options.RunSize <= 0 {
+		options.RunSize = defaultExternalSortRunSize
+	}
+	if options.Codec == nil {
+		options.Codec = GobCodec{}
+	}
+	res = &externalSortStream{
+		input:   input,
+		lessFn:  lessFn,
+		options: options,
+	}
+	return
+}
+
+func (es *externalSortStream) Next() (interface{}, bool) {
+	// a panic of lessFn leaves the runs unsorted, so the stream can not go
+	// on, and the spilled runs are removed
+	defer func() {
+		if r := recover(); r != nil {
+			es.fail(fmt.Errorf("streamer: sort aborted by a panic: %v", r))
+			abortingPanic(r)
+		}
+	}()
+
+	if !es.started {
+		es.started = true
+		if err := es.spill(); err != nil {
+			es.fail(err)
+		}
+	}
+	if es.err != nil {
+		return nil, false
+	}
+
+	if es.runs == nil {
+		if len(es.buffer) == 0 {
+			return nil, false
+		}
+		item := es.buffer[0]
+		es.buffer = es.buffer[1:]
+		return item, true
+	}
+
+	if es.merger.Len() == 0 {
+		es.cleanup()
+		return nil, false
+	}
+
+	run := es.merger.runs[0]
+	item := run.head
+	if err := run.advance(); err != nil {
+		es.fail(err)
+		return nil, false
+	}
+	if run.done {
+		heap.Pop(&es.merger)
+	} else {
+		heap.Fix(&es.merger, 0)
+	}
+	return item, true
+}
+
+func (es *externalSortStream) Err() error { return es.err }
+
+// Close removes the temp files of the spilled runs.
+func (es *externalSortStream) Close() error { return es.cleanup() }
+
+func (es *externalSortStream) spill() error {
+	for item, ok := es.input.Next(); ok; item, ok = es.input.Next() {
+		es.buffer = append(es.buffer, item)
+		if len(es.buffer) < es.options.RunSize {
+			continue
+		}
+		if err := es.writeRun(); err != nil {
+			return err
+		}
+	}
+
+	es.sortBuffer()
+	if es.runs == nil {
+		return nil
+	}
+	if len(es.buffer) > 0 {
+		if err := es.writeRun(); err != nil {
+			return err
+		}
+	}
+
+	es.merger = runHeap{lessFn: es.lessFn}
+	for _, run := range es.runs {
+		if err := run.open(es.options.Codec); err != nil {
+			return err
+		}
+		if !run.done {
+			es.merger.runs = append(es.merger.runs, run)
+		}
+	}
+	heap.Init(&es.merger)
+	return nil
+}
+
+func (es *externalSortStream) sortBuffer() {
+	sort.SliceStable(es.buffer, func(i, j int) bool { return es.lessFn(es.buffer[i], es.buffer[j]) })
+}
+
+func (es *externalSortStream) writeRun() (err error) {
+	es.sortBuffer()
+
+	file, err := ioutil.TempFile(es.options.TempDir, "streamer-sort-*")
+	if err != nil {
+		return err
+	}
+	es.runs = append(es.runs, &sortRun{index: len(es.runs), path: file.Name()})
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+	enc := es.options.Codec.NewEncoder(w)
+	for _, item := range es.buffer {
+		if err = enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	es.buffer = es.buffer[:0]
+	return w.Flush()
+}
+
+func (es *externalSortStream) fail(err error) {
+	es.err = err
+	es.cleanup()
+}
+
+func (es *externalSortStream) cleanup() error {
+	var firstErr error
+	for _, run := range es.runs {
+		if err := run.remove(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	es.runs = nil
+	es.buffer = nil
+	es.merger.runs = nil
+	return firstErr
+}
+
+//
+
+type sortRun struct {
+	index int
+	path  string
+
+	file *os.File
+	dec  ItemDecoder
+	head interface{}
+	done bool
+}
+
+func (sr *sortRun) open(codec Codec) error {
+	file, err := os.Open(sr.path)
+	if err != nil {
+		return err
+	}
+	sr.file = file
+	sr.dec = codec.NewDecoder(bufio.NewReader(file))
+	return sr.advance()
+}
+
+func (sr *sortRun) advance() error {
+	item, err := sr.dec.Decode()
+	if err == io.EOF {
+		sr.head = nil
+		sr.done = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sr.head = item
+	return nil
+}
+
+func (sr *sortRun) remove() error {
+	if sr.file != nil {
+		sr.file.Close()
+		sr.file = nil
+	}
+	err := os.Remove(sr.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+//
+
+type runHeap struct {
+	runs   []*sortRun
+	lessFn func(a, b interface{}) bool
+}
+
+func (rh runHeap) Len() int { return len(rh.runs) }
+
+func (rh runHeap) Less(i, j int) bool {
+	a, b := rh.runs[i], rh.runs[j]
+	if rh.lessFn(a.head, b.head) {
+		return true
+	}
+	if rh.lessFn(b.head, a.head) {
+		return false
+	}
+	return a.index < b.index
+}
+
+func (rh runHeap) Swap(i, j int) { rh.runs[i], rh.runs[j] = rh.runs[j], rh.runs[i] }
+
+func (rh *runHeap) Push(x interface{}) { rh.runs = append(rh.runs, x.(*sortRun)) }
+
+func (rh *runHeap) Pop() interface{} {
+	last := rh.runs[len(rh.runs)-1]
+	rh.runs = rh.runs[:len(rh.runs)-1]
+	return last
+}
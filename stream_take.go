@@ -24,3 +24,16 @@ func (tc *takeStream) Next() (interface{}, bool) {
 	tc.takeCount--
 	return item, true
 }
+
+func (tc *takeStream) Snapshot() (*State, error) {
+	return stageSnapshot("take", tc.takeCount, tc.input)
+}
+
+func (tc *takeStream) Restore(state *State) error {
+	count, err := restoreStage("take", state, tc.input)
+	if err != nil {
+		return err
+	}
+	tc.takeCount = count
+	return nil
+}
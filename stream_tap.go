@@ -0,0 +1,30 @@
+package streamer
+
+type tapStream struct {
+	input Iterator
+	tapFn func(interface{})
+}
+
+func newTapStream(input Iterator, tapFn func(interface{})) (res *tapStream) {
+	res = &tapStream{
+		input: input,
+		tapFn: tapFn,
+	}
+	return
+}
+
+func (ts *tapStream) Next() (interface{}, bool) {
+	item, ok := ts.input.Next()
+	if !ok {
+		return nil, false
+	}
+	ts.tapFn(item)
+	return item, true
+}
+
+func (ts *tapStream) Snapshot() (*State, error) { return stageSnapshot("tap", 0, ts.input) }
+
+func (ts *tapStream) Restore(state *State) error {
+	_, err := restoreStage("tap", state, ts.input)
+	return err
+}
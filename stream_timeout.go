@@ -0,0 +1,148 @@
+package streamer
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+var ErrTimeout = errors.New("streamer: timed out waiting for the next element")
+
+// TimeoutMode decides how a Timeout stage ends the stream.
+type TimeoutMode int
+
+const (
+	// TimeoutFail ends the stream and reports ErrTimeout through Err.
+	TimeoutFail TimeoutMode = iota
+	// TimeoutEnd ends the stream as if the input was exhausted.
+	TimeoutEnd
+)
+
+type timeoutResult struct {
+	item     interface{}
+	ok       bool
+	panicked bool
+	panicVal interface{}
+}
+
+// timeoutWorker calls Next of the input on request, in a goroutine reused for
+// all elements. Like bufferProducer, it does not reference the timeoutStream,
+// so an abandoned stream can be collected and its finalizer can stop it.
+type timeoutWorker struct {
+	input    Iterator
+	upstream *detachedUpstream
+	requests chan struct{}
+	results  chan timeoutResult
+	done     chan struct{}
+
+	stopOnce sync.Once
+}
+
+func (tw *timeoutWorker) run() {
+	defer close(tw.done)
+	defer tw.upstream.finish()
+	for range tw.requests {
+		// results has room for the single pending request
+		res := tw.next()
+		tw.results <- res
+		if !res.ok {
+			return
+		}
+	}
+}
+
+func (tw *timeoutWorker) next() (res timeoutResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			res.panicked, res.panicVal = true, r
+		}
+	}()
+	res.item, res.ok = tw.input.Next()
+	return
+}
+
+func (tw *timeoutWorker) halt() {
+	tw.stopOnce.Do(func() { close(tw.requests) })
+}
+
+type timeoutStream struct {
+	worker  *timeoutWorker
+	timeout time.Duration
+	mode    TimeoutMode
+	clock   Clock
+
+	started bool
+	done    bool
+	err     error
+}
+
+func newTimeoutStream(input Iterator, timeout time.Duration, mode TimeoutMode, clock Clock, upstream *Stream) (res *timeoutStream) {
+	res = &timeoutStream{
+		worker: &timeoutWorker{
+			input:    input,
+			upstream: &detachedUpstream{chain: upstream},
+			requests: make(chan struct{}, 1),
+			results:  make(chan timeoutResult, 1),
+			done:     make(chan struct{}),
+		},
+		timeout: timeout,
+		mode:    mode,
+		clock:   clock,
+	}
+	runtime.SetFinalizer(res, func(ts *timeoutStream) { ts.worker.halt() })
+	return
+}
+
+func (ts *timeoutStream) Next() (interface{}, bool) {
+	if ts.done {
+		return nil, false
+	}
+	if !ts.started {
+		ts.started = true
+		go ts.worker.run()
+	}
+
+	ts.worker.requests <- struct{}{}
+
+	timer := ts.clock.NewTimer(ts.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-ts.worker.results:
+		if !res.ok {
+			ts.done = true
+			<-ts.worker.done
+		}
+		if res.panicked {
+			panic(res.panicVal)
+		}
+		return res.item, res.ok
+	case <-timer.C():
+		// the call of the input keeps running; the worker closes the input
+		// once it returns and the stream is closed
+		ts.done = true
+		if ts.mode == TimeoutFail {
+			ts.err = ErrTimeout
+		}
+		return nil, false
+	}
+}
+
+func (ts *timeoutStream) Err() error {
+	if ts.err != nil {
+		return ts.err
+	}
+	return ts.worker.upstream.Err(ts.started)
+}
+
+// Close stops the worker goroutine without waiting for the Next call of the
+// input it may be running; the worker closes the input once that call
+// returns.
+func (ts *timeoutStream) Close() error {
+	ts.done = true
+	ts.worker.halt()
+	return ts.worker.upstream.close(ts.started)
+}
+
+func (ts *timeoutStream) pullsConcurrently() {}
@@ -0,0 +1,115 @@
+package streamer
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrWindowSize = errors.New("streamer: window size must be positive")
+
+// WindowOptions configures WindowByTime.
+type WindowOptions struct {
+	// AllowedLateness holds the watermark back from the latest timestamp
+	// seen, so elements arriving out of order by up to AllowedLateness still
+	// land in their windows. Later elements are dropped.
+	AllowedLateness time.Duration
+}
+
+type timeWindow struct {
+	start time.Time
+	items []interface{}
+}
+
+type windowByTimeStream struct {
+	input       Iterator
+	size        time.Duration
+	slide       time.Duration
+	timestampFn func(x interface{}) time.Time
+	lateness    time.Duration
+
+	// open windows, ordered by start
+	open     []*timeWindow
+	ready    [][]interface{}
+	latest   time.Time
+	seenItem bool
+	done     bool
+	err      error
+}
+
+func newWindowByTimeStream(input Iterator, size, slide time.Duration, timestampFn func(x interface{}) time.Time, options WindowOptions) (res *windowByTimeStream) {
+	if slide <= 0 {
+		slide = size
+	}
+	if options.AllowedLateness < 0 {
+		options.AllowedLateness = 0
+	}
+	res = &windowByTimeStream{
+		input:       input,
+		size:        size,
+		slide:       slide,
+		timestampFn: timestampFn,
+		lateness:    options.AllowedLateness,
+	}
+	if size <= 0 {
+		res.err, res.done = ErrWindowSize, true
+	}
+	return
+}
+
+func (wt *windowByTimeStream) Next() (interface{}, bool) {
+	for len(wt.ready) == 0 {
+		if wt.done {
+			return nil, false
+		}
+
+		item, ok := wt.input.Next()
+		if !ok {
+			wt.done = true
+			for _, window := range wt.open {
+				wt.ready = append(wt.ready, window.items)
+			}
+			wt.open = nil
+			continue
+		}
+
+		ts := wt.timestampFn(item)
+		if !wt.seenItem || ts.After(wt.latest) {
+			wt.latest, wt.seenItem = ts, true
+		}
+		watermark := wt.latest.Add(-wt.lateness)
+
+		// windows already passed by the watermark have been emitted
+		for start := ts.Truncate(wt.slide); start.Add(wt.size).After(ts); start = start.Add(-wt.slide) {
+			if start.Add(wt.size).After(watermark) {
+				window := wt.window(start)
+				window.items = append(window.items, item)
+			}
+		}
+
+		for len(wt.open) > 0 && !wt.open[0].start.Add(wt.size).After(watermark) {
+			wt.ready = append(wt.ready, wt.open[0].items)
+			wt.open = wt.open[1:]
+		}
+	}
+
+	chunk := wt.ready[0]
+	wt.ready = wt.ready[1:]
+	return chunk, true
+}
+
+func (wt *windowByTimeStream) Err() error { return wt.err }
+
+// window returns the open window starting at start, opening it if needed.
+func (wt *windowByTimeStream) window(start time.Time) *timeWindow {
+	i := sort.Search(len(wt.open), func(i int) bool { return !wt.open[i].start.Before(start) })
+	if i < len(wt.open) && wt.open[i].start.Equal(start) {
+		return wt.open[i]
+	}
+
+	window := &timeWindow{start: start}
+	wt.open = append(wt.open, nil)
+	copy(wt.open[i+1:], wt.open[i:])
+	wt.open[i] = window
+	return window
+}
@@ -1,5 +1,13 @@
 package streamer
 
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
 type Iterator interface {
 	Next() (interface{}, bool)
 }
@@ -7,7 +15,15 @@ type Iterator interface {
 //
 
 type Stream struct {
-	input Iterator
+	input       Iterator
+	upstream    *Stream
+	observer    Observer
+	panicPolicy PanicPolicy
+	clock       Clock
+
+	// label describes the stage, like Take(10); name is set by Named
+	label string
+	name  string
 }
 
 func NewStream(input Iterator) (res *Stream) {
@@ -17,42 +33,460 @@ func NewStream(input Iterator) (res *Stream) {
 
 func (st *Stream) Next() (interface{}, bool) { return st.input.Next() }
 
+// Err returns the first error reported by an iterator in the chain, starting
+// from the last stage. Iterators report errors by implementing Err() error.
+func (st *Stream) Err() error {
+	for s := st; s != nil; s = s.upstream {
+		if err := iteratorErr(s.input); err != nil {
+			return err
+		}
+		if ownsUpstream(s.input) {
+			break
+		}
+	}
+	return nil
+}
+
+// Close closes every iterator in the chain that implements io.Closer and
+// returns the first error.
+func (st *Stream) Close() error {
+	var firstErr error
+	for s := st; s != nil; s = s.upstream {
+		if err := closeIterator(s.input); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ownsUpstream(s.input) {
+			break
+		}
+	}
+	return firstErr
+}
+
+// ownsUpstream reports whether the stage reports the errors of its upstream
+// chain and closes it, as stages pulling it in another goroutine do.
+func ownsUpstream(it Iterator) bool {
+	_, owns := unwrapStage(it).(concurrentStage)
+	return owns
+}
+
+func iteratorErr(it Iterator) error {
+	if errIterator, ok := it.(interface{ Err() error }); ok {
+		return errIterator.Err()
+	}
+	return nil
+}
+
+func closeIterator(it Iterator) error {
+	if closer, ok := it.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// concurrentStage is implemented by stages that pull their input in another
+// goroutine.
+type concurrentStage interface {
+	pullsConcurrently()
+}
+
+// detachedUpstream is the upstream chain of a stage pulling it in another
+// goroutine. The goroutine owns the chain: after its last Next call it
+// records the error of the chain, and closes the chain if the stage was
+// closed meanwhile, so closing the stage never waits for a pending Next call.
+type detachedUpstream struct {
+	chain *Stream
+
+	mu        sync.Mutex
+	finished  bool
+	closing   bool
+	err       error
+	closeOnce sync.Once
+}
+
+// finish is called by the goroutine after its last Next call.
+func (du *detachedUpstream) finish() {
+	err := du.chain.Err()
+	du.mu.Lock()
+	du.finished, du.err = true, err
+	closing := du.closing
+	du.mu.Unlock()
+	if closing {
+		du.closeChain()
+	}
+}
+
+// close closes the chain if no goroutine is pulling it, or leaves it to the
+// goroutine to close once its pending Next call returns.
+func (du *detachedUpstream) close(started bool) error {
+	du.mu.Lock()
+	du.closing = true
+	idle := !started || du.finished
+	du.mu.Unlock()
+	if idle {
+		return du.closeChain()
+	}
+	return nil
+}
+
+func (du *detachedUpstream) closeChain() (err error) {
+	du.closeOnce.Do(func() { err = du.chain.Close() })
+	return
+}
+
+// Err returns the error of the chain, which is known once the goroutine has
+// finished.
+func (du *detachedUpstream) Err(started bool) error {
+	if !started {
+		return du.chain.Err()
+	}
+	du.mu.Lock()
+	defer du.mu.Unlock()
+	return du.err
+}
+
+func (st *Stream) pipe(label string, iterator Iterator) *Stream {
+	_, concurrent := iterator.(concurrentStage)
+	if st.panicPolicy != PanicPropagate {
+		iterator = newRecoveringIterator(iterator, label, st.panicPolicy, st)
+	}
+	if st.observer != nil {
+		upstream, _ := st.input.(*observedIterator)
+		observed := newObservedIterator(iterator, label, st.observer, upstream, st.clockOrDefault())
+		observed.detached = concurrent
+		iterator = observed
+	}
+	res := NewStream(iterator)
+	res.upstream = st
+	res.observer = st.observer
+	res.panicPolicy = st.panicPolicy
+	res.clock = st.clock
+	res.label = label
+	return res
+}
+
+// Observe reports per-stage metrics of the stream, and of the stages added
+// after it, to observer. The stream so far is reported as a single stage.
+func (st *Stream) Observe(observer Observer) *Stream {
+	res := NewStream(newObservedIterator(st.input, st.stageName(), observer, nil, st.clockOrDefault()))
+	// the observed iterator takes the place of st in the chain
+	res.upstream = st.upstream
+	res.observer = observer
+	res.panicPolicy = st.panicPolicy
+	res.clock = st.clock
+	res.label = st.stageLabel()
+	res.name = st.name
+	return res
+}
+
 func (st *Stream) Map(mapFn func(x interface{}) interface{}) *Stream {
-	iterator := newMapperStream(st.input, mapFn)
-	return NewStream(iterator)
+	iterator := newMapperStream(st.input, st.guardMapFn(mapFn))
+	return st.pipe("Map", iterator)
 }
 
 func (st *Stream) ChunkBy(chunkFn func(x interface{}) interface{}) *Stream {
-	iterator := newChunkByStream(st.input, chunkFn)
-	return NewStream(iterator)
+	iterator := newChunkByStream(st.input, st.guardMapFn(chunkFn))
+	return st.pipe("ChunkBy", iterator)
 }
 
 func (st *Stream) ChunkEvery(chunkSize int) *Stream {
 	iterator := newChunkEveryStream(st.input, chunkSize)
-	return NewStream(iterator)
+	return st.pipe(fmt.Sprintf("ChunkEvery(%d)", chunkSize), iterator)
+}
+
+// WindowByTime groups elements into windows of size by the event time
+// returned by timestampFn, and emits each window as a chunk. Windows start
+// every slide, so a slide equal to size, or <= 0, gives tumbling windows, a
+// shorter one gives overlapping, sliding windows, and a longer one leaves gaps
+// between windows, whose elements are dropped. A size <= 0 ends the stream
+// with ErrWindowSize. A window is emitted once the watermark, the latest
+// timestamp seen minus options.AllowedLateness, passes its end; open windows
+// are emitted when the input ends. Empty windows are not emitted.
+func (st *Stream) WindowByTime(size, slide time.Duration, timestampFn func(x interface{}) time.Time, options WindowOptions) *Stream {
+	iterator := newWindowByTimeStream(st.input, size, slide, st.guardTimestampFn(timestampFn), options)
+	return st.pipe(fmt.Sprintf("WindowByTime(%v, %v)", size, slide), iterator)
 }
 
 func (st *Stream) Skip(skipCount int) *Stream {
 	iterator := newSkipStream(st.input, skipCount)
-	return NewStream(iterator)
+	return st.pipe(fmt.Sprintf("Skip(%d)", skipCount), iterator)
 }
 
 func (st *Stream) SkipWhile(skipFn func(interface{}) bool) *Stream {
-	iterator := newSkipWhileStream(st.input, skipFn)
-	return NewStream(iterator)
+	iterator := newSkipWhileStream(st.input, st.guardPredicate(skipFn))
+	return st.pipe("SkipWhile", iterator)
 }
 
 func (st *Stream) Filter(filterFn func(interface{}) bool) *Stream {
-	iterator := newFilterStream(st.input, filterFn)
-	return NewStream(iterator)
+	iterator := newFilterStream(st.input, st.guardPredicate(filterFn))
+	return st.pipe("Filter", iterator)
 }
 
 func (st *Stream) Take(takeCount int) *Stream {
 	iterator := newTakeStream(st.input, takeCount)
-	return NewStream(iterator)
+	return st.pipe(fmt.Sprintf("Take(%d)", takeCount), iterator)
 }
 
 func (st *Stream) TakeWhile(takeFn func(interface{}) bool) *Stream {
-	iterator := newTakeWhileStream(st.input, takeFn)
-	return NewStream(iterator)
+	iterator := newTakeWhileStream(st.input, st.guardPredicate(takeFn))
+	return st.pipe("TakeWhile", iterator)
+}
+
+// SortExternal sorts the stream using an external merge sort: runs of
+// options.RunSize items are sorted in memory and spilled to temp files, which
+// are lazily merged back. Temp files are removed once the stream is exhausted,
+// fails or is closed.
+func (st *Stream) SortExternal(lessFn func(a, b interface{}) bool, options ExternalSortOptions) *Stream {
+	iterator := newExternalSortStream(st.input, st.guardLessFn(lessFn), options)
+	return st.pipe("SortExternal", iterator)
+}
+
+// Sum consumes the stream and returns the sum of its numeric elements, as a
+// float64. Integer elements are added exactly, and rounded once, so the result
+// is exact as long as it fits in the 53 bits of a float64 mantissa.
+func (st *Stream) Sum() (float64, error) { return sumOf(st) }
+
+// Min consumes the stream and returns its smallest numeric element.
+func (st *Stream) Min() (interface{}, error) { return extremeBy(st, identity, lessFloat64) }
+
+// Max consumes the stream and returns its largest numeric element.
+func (st *Stream) Max() (interface{}, error) { return extremeBy(st, identity, greaterFloat64) }
+
+// MinBy consumes the stream and returns the element with the smallest numeric key.
+func (st *Stream) MinBy(keyFn func(interface{}) interface{}) (interface{}, error) {
+	return extremeBy(st, keyFn, lessFloat64)
+}
+
+// MaxBy consumes the stream and returns the element with the largest numeric key.
+func (st *Stream) MaxBy(keyFn func(interface{}) interface{}) (interface{}, error) {
+	return extremeBy(st, keyFn, greaterFloat64)
+}
+
+// Average consumes the stream and returns the mean of its numeric elements.
+func (st *Stream) Average() (float64, error) { return averageOf(st) }
+
+// Stats consumes the stream and computes count, sum, min, max, mean, variance
+// and standard deviation in one pass.
+func (st *Stream) Stats() (Stats, error) { return statsOf(st) }
+
+// QuantileSketch consumes the stream into a t-digest for estimating
+// percentiles. Sketches of different shards can be combined with Merge.
+func (st *Stream) QuantileSketch(compression float64) (*TDigest, error) {
+	return quantileSketchOf(st, compression)
+}
+
+// Histogram consumes the stream and counts its numeric elements in buckets
+// with the given upper bounds.
+func (st *Stream) Histogram(bounds ...float64) (*Histogram, error) { return histogramOf(st, bounds) }
+
+// Cycle repeats the stream forever. The first pass is buffered and replayed.
+func (st *Stream) Cycle() *Stream {
+	iterator := newCycleStream(st.input, -1)
+	return st.pipe("Cycle", iterator)
+}
+
+// CycleN repeats the stream n times.
+func (st *Stream) CycleN(n int) *Stream {
+	if n < 0 {
+		n = 0
+	}
+	iterator := newCycleStream(st.input, n)
+	return st.pipe(fmt.Sprintf("CycleN(%d)", n), iterator)
+}
+
+// WriteJSONLines drains the stream into w, one JSON value per line.
+func (st *Stream) WriteJSONLines(w io.Writer) error { return writeJSONLines(st, w) }
+
+// WriteCSV drains the stream into w as CSV records. Items can be []string,
+// map[string]string or structs (tagged as for CSVOptions.New). If header is
+// not nil it is written first; for structs it defaults to their fields.
+func (st *Stream) WriteCSV(w io.Writer, header []string) error { return writeCSV(st, w, header) }
+
+// Keys maps a stream of Pair elements to their keys. Like the other Pair
+// operators, it ends the stream with a NotPairError on any other element.
+func (st *Stream) Keys() *Stream {
+	iterator := newPairStream(st.input, func(pair Pair) (interface{}, bool) { return pair.Key, true })
+	return st.pipe("Keys", iterator)
+}
+
+// Values maps a stream of Pair elements to their values.
+func (st *Stream) Values() *Stream {
+	iterator := newPairStream(st.input, func(pair Pair) (interface{}, bool) { return pair.Value, true })
+	return st.pipe("Values", iterator)
+}
+
+// MapValues maps the values of a stream of Pair elements, keeping their keys.
+func (st *Stream) MapValues(mapFn func(x interface{}) interface{}) *Stream {
+	mapFn = st.guardMapFn(mapFn)
+	iterator := newPairStream(st.input, func(pair Pair) (interface{}, bool) {
+		return Pair{Key: pair.Key, Value: mapFn(pair.Value)}, true
+	})
+	return st.pipe("MapValues", iterator)
+}
+
+// FilterKeys keeps the Pair elements whose key satisfies filterFn.
+func (st *Stream) FilterKeys(filterFn func(interface{}) bool) *Stream {
+	filterFn = st.guardPredicate(filterFn)
+	iterator := newPairStream(st.input, func(pair Pair) (interface{}, bool) { return pair, filterFn(pair.Key) })
+	return st.pipe("FilterKeys", iterator)
+}
+
+// ToMap consumes a stream of Pair elements into a map. Later keys replace
+// earlier ones. Elements that are not Pairs are reported as a NotPairError,
+// and keys that can not be map keys as an UnhashableKeyError.
+func (st *Stream) ToMap() (map[interface{}]interface{}, error) { return toMap(st) }
+
+// Enumerate yields each element as a Pair, with its zero-based position in
+// the stream as the key. Placed right after the source, it keeps the source
+// positions through later stages that drop elements, for MapIndexed and
+// FilterIndexed.
+func (st *Stream) Enumerate() *Stream {
+	iterator := newEnumerateStream(st.input)
+	return st.pipe("Enumerate", iterator)
+}
+
+// MapIndexed is like Map, but mapFn also receives the element's zero-based
+// position in the source. Positions are carried by the Pairs of an Enumerate
+// stage: if the elements are passed on unchanged from Enumerate, through
+// stages like Filter, SkipWhile, Skip or Take, mapFn receives the key of each
+// Pair as the position, and its value as x. Otherwise, the position is
+// counted in the stream MapIndexed is attached to.
+func (st *Stream) MapIndexed(mapFn func(index int, x interface{}) interface{}) *Stream {
+	iterator := newIndexedStream(st.input, st.guardIndexedFn(func(index int, x interface{}) (interface{}, bool) {
+		return mapFn(index, x), true
+	}), st.enumerated(), false)
+	return st.pipe("MapIndexed", iterator)
+}
+
+// FilterIndexed is like Filter, but filterFn also receives the element's
+// zero-based position in the source, as for MapIndexed. The Pairs of an
+// Enumerate stage are kept as they are, so later stages still see the
+// positions.
+func (st *Stream) FilterIndexed(filterFn func(index int, x interface{}) bool) *Stream {
+	iterator := newIndexedStream(st.input, st.guardIndexedFn(func(index int, x interface{}) (interface{}, bool) {
+		return nil, filterFn(index, x)
+	}), st.enumerated(), true)
+	return st.pipe("FilterIndexed", iterator)
+}
+
+// enumerated reports whether the elements of st are the Pairs of an
+// Enumerate stage, passed on unchanged by the stages after it.
+func (st *Stream) enumerated() bool {
+	for s := st; s != nil; s = s.upstream {
+		switch it := unwrapStage(s.input).(type) {
+		case *enumerateStream:
+			return true
+		case *filterStream, *skipWhileStream, *skipStream, *takeStream, *takeWhileStream, *tapStream, *logStream:
+		case *indexedStream:
+			if !it.keepsElements {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// unwrapStage returns the stage iterator inside the observing and recovering
+// wrappers.
+func unwrapStage(it Iterator) Iterator {
+	for {
+		switch wrapper := it.(type) {
+		case *observedIterator:
+			it = wrapper.input
+		case *recoveringIterator:
+			it = wrapper.input
+		default:
+			return it
+		}
+	}
+}
+
+// Snapshot returns the state of the stream, if all its stages and its source
+// are Snapshotters.
+func (st *Stream) Snapshot() (*State, error) { return snapshotOf(st.input) }
+
+// Restore resumes the stream from a state taken from an identical pipeline.
+func (st *Stream) Restore(state *State) error { return restoreTo(st.input, state) }
+
+// Tap calls tapFn with each element, passing the elements on unchanged.
+func (st *Stream) Tap(tapFn func(interface{})) *Stream {
+	iterator := newTapStream(st.input, st.guardTapFn(tapFn))
+	return st.pipe("Tap", iterator)
+}
+
+// Log emits an info record with the given message for each element, with the
+// element and its zero-based index as attributes.
+func (st *Stream) Log(logger *slog.Logger, label string) *Stream {
+	iterator := newLogStream(st.input, logger, label, 1)
+	return st.pipe("Log", iterator)
+}
+
+// LogEvery is like Log, but emits a record only for every nth element, with
+// the count of elements seen so far.
+func (st *Stream) LogEvery(logger *slog.Logger, label string, n int) *Stream {
+	if n < 1 {
+		n = 1
+	}
+	iterator := newLogStream(st.input, logger, label, n)
+	return st.pipe(fmt.Sprintf("LogEvery(%d)", n), iterator)
+}
+
+// MapWithRetry is like Map for fallible functions: failed calls are retried
+// with exponential backoff, as configured by policy. If an element still
+// fails, the stream ends and Err reports the last error.
+func (st *Stream) MapWithRetry(mapFn func(x interface{}) (interface{}, error), policy RetryPolicy) *Stream {
+	iterator := newRetryStream(st.input, st.guardFallibleFn(mapFn), policy, st.clockOrDefault())
+	return st.pipe("MapWithRetry", iterator)
+}
+
+// RateLimit delays elements so that at most limit of them pass per period,
+// allowing bursts of up to limit elements. An element is pulled from upstream
+// before waiting for its turn. A limit < 1 is taken as 1, and a period <= 0
+// does not limit the rate.
+func (st *Stream) RateLimit(limit int, period time.Duration) *Stream {
+	if limit < 1 {
+		limit = 1
+	}
+	iterator := newRateLimitStream(st.input, limit, period, st.clockOrDefault())
+	return st.pipe(fmt.Sprintf("RateLimit(%d, %v)", limit, period), iterator)
+}
+
+// Throttle passes an element, then drops the elements that arrive within
+// window after it.
+func (st *Stream) Throttle(window time.Duration) *Stream {
+	iterator := newThrottleStream(st.input, window, st.clockOrDefault())
+	return st.pipe(fmt.Sprintf("Throttle(%v)", window), iterator)
+}
+
+// Debounce passes only the last element of each burst: an element is dropped
+// if the next one arrives within quietFor. As elements are pulled, an element
+// is passed on once the next one arrives, or the stream ends.
+func (st *Stream) Debounce(quietFor time.Duration) *Stream {
+	iterator := newDebounceStream(st.input, quietFor, st.clockOrDefault())
+	return st.pipe(fmt.Sprintf("Debounce(%v)", quietFor), iterator)
+}
+
+// Timeout ends the stream if the input does not produce the next element
+// within timeout; with TimeoutFail, Err reports ErrTimeout. The input is
+// pulled in a goroutine, whose Next call is left running after a timeout;
+// Close does not wait for it, and the goroutine closes the input once it
+// returns. Errors of the input are reported by Err once the input is
+// exhausted, or after a timeout once the pending call has returned and the
+// stream is closed.
+func (st *Stream) Timeout(timeout time.Duration, mode TimeoutMode) *Stream {
+	iterator := newTimeoutStream(st.input, timeout, mode, st.clockOrDefault(), st)
+	return st.pipe(fmt.Sprintf("Timeout(%v)", timeout), iterator)
+}
+
+// Buffer pulls the input in a goroutine, started by the first Next call, and
+// keeps up to n elements ready, so a slow producer and a slow consumer
+// overlap. Panics of the input are raised again by Next. The goroutine stops
+// when the input is exhausted, or the stream is closed or garbage collected.
+// Close does not wait for a pending Next call of the input; the goroutine
+// closes the input once it returns. Errors of the input are reported by Err
+// once the stream is exhausted.
+func (st *Stream) Buffer(n int) *Stream {
+	iterator := newBufferStream(st.input, n, st)
+	return st.pipe(fmt.Sprintf("Buffer(%d)", n), iterator)
 }
@@ -1,11 +1,23 @@
 package streamer_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/dc0d/streamer"
+	"github.com/dc0d/streamer/streamertest"
 
 	assert "github.com/stretchr/testify/require"
 )
@@ -122,6 +134,15 @@ func Test_stream_chunk_by(t *testing.T) {
 					return err == nil
 				},
 			},
+			{
+				[]T{nil, nil, 1, nil},
+				[]T{
+					[]T{nil, nil},
+					[]T{1},
+					[]T{nil},
+				},
+				func(x T) T { return x == nil },
+			},
 		}
 	)
 
@@ -613,3 +634,1217 @@ func Test_chain_calls(t *testing.T) {
 
 	assert.Equal(len(expectedOutput), index)
 }
+
+func Test_stream_sort_external(t *testing.T) {
+	type (
+		expectation struct {
+			input          []T
+			expectedOutput []T
+			runSize        int
+			codec          streamer.Codec
+		}
+	)
+
+	var (
+		lessFn = func(a, b T) bool { return a.(int) < b.(int) }
+
+		expectations = []expectation{
+			{
+				nil,
+				nil,
+				2,
+				nil,
+			},
+			{
+				[]T{3, 1, 2},
+				[]T{1, 2, 3},
+				10,
+				nil,
+			},
+			{
+				[]T{5, 3, 9, 1, 7, 2, 8, 4, 6},
+				[]T{1, 2, 3, 4, 5, 6, 7, 8, 9},
+				2,
+				nil,
+			},
+			{
+				[]T{5, 3, 3, 1, 5, 2, 1},
+				[]T{1, 1, 2, 3, 3, 5, 5},
+				3,
+				streamer.GobCodec{},
+			},
+			{
+				[]T{5, 3, 9, 1, 7, 2},
+				[]T{1, 2, 3, 5, 7, 9},
+				4,
+				streamer.JSONCodec{New: func() interface{} { return new(int) }},
+			},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			input          = exp.input
+			expectedOutput = exp.expectedOutput
+			runSize        = exp.runSize
+			codec          = exp.codec
+		)
+
+		t.Run(fmt.Sprintf("stream sort external, test case %v", i+1), func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+
+				iterator streamer.Iterator = streamer.NewSliceIterator(input)
+				stream                     = streamer.NewStream(iterator)
+			)
+
+			tempDir, err := ioutil.TempDir("", "streamer-test")
+			assert.NoError(err)
+			defer os.RemoveAll(tempDir)
+
+			stream = stream.SortExternal(lessFn, streamer.ExternalSortOptions{
+				RunSize: runSize,
+				Codec:   codec,
+				TempDir: tempDir,
+			})
+
+			index := 0
+			for item, ok := stream.Next(); ok; item, ok = stream.Next() {
+				assert.Equal(expectedOutput[index], item)
+				index++
+			}
+
+			assert.Equal(len(expectedOutput), index)
+			assert.NoError(stream.Err())
+
+			files, err := ioutil.ReadDir(tempDir)
+			assert.NoError(err)
+			assert.Empty(files)
+		})
+	}
+
+	t.Run("stream sort external, close removes temp files", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = []T{4, 3, 2, 1}
+			stream = streamer.NewStream(streamer.NewSliceIterator(input))
+		)
+
+		tempDir, err := ioutil.TempDir("", "streamer-test")
+		assert.NoError(err)
+		defer os.RemoveAll(tempDir)
+
+		stream = stream.SortExternal(lessFn, streamer.ExternalSortOptions{RunSize: 1, TempDir: tempDir})
+
+		item, ok := stream.Next()
+		assert.True(ok)
+		assert.Equal(1, item)
+
+		files, err := ioutil.ReadDir(tempDir)
+		assert.NoError(err)
+		assert.Len(files, 4)
+
+		assert.NoError(stream.Close())
+
+		files, err = ioutil.ReadDir(tempDir)
+		assert.NoError(err)
+		assert.Empty(files)
+	})
+
+	t.Run("stream sort external, reports spill errors", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = []T{4, 3, 2, 1}
+			stream = streamer.NewStream(streamer.NewSliceIterator(input))
+		)
+
+		stream = stream.SortExternal(lessFn, streamer.ExternalSortOptions{
+			RunSize: 1,
+			TempDir: filepath.Join(os.TempDir(), "streamer-test-missing-dir"),
+		})
+
+		_, ok := stream.Next()
+		assert.False(ok)
+		assert.Error(stream.Err())
+	})
+}
+
+func Test_stream_numeric(t *testing.T) {
+	type (
+		expectation struct {
+			input   []T
+			sum     float64
+			min     T
+			max     T
+			average float64
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{[]T{1, 2, 3}, 6, 1, 3, 2},
+			{[]T{2.5, -1.5, 3.0}, 4, -1.5, 3.0, 4.0 / 3},
+			{[]T{int8(4), uint16(2), float32(6)}, 12, uint16(2), float32(6), 4},
+			{[]T{7}, 7, 7, 7, 7},
+		}
+	)
+
+	newStream := func(input []T) *streamer.Stream {
+		return streamer.NewStream(streamer.NewSliceIterator(input))
+	}
+
+	for i, exp := range expectations {
+		exp := exp
+		input := exp.input
+
+		t.Run(fmt.Sprintf("stream numeric, test case %v", i+1), func(t *testing.T) {
+			assert := assert.New(t)
+
+			sum, err := newStream(input).Sum()
+			assert.NoError(err)
+			assert.InDelta(exp.sum, sum, 1e-9)
+
+			min, err := newStream(input).Min()
+			assert.NoError(err)
+			assert.Equal(exp.min, min)
+
+			max, err := newStream(input).Max()
+			assert.NoError(err)
+			assert.Equal(exp.max, max)
+
+			average, err := newStream(input).Average()
+			assert.NoError(err)
+			assert.InDelta(exp.average, average, 1e-9)
+		})
+	}
+
+	t.Run("stream numeric, min by and max by", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []T{"ccc", "a", "bb", "d"}
+			keyFn = func(x T) T { return len(x.(string)) }
+		)
+
+		min, err := newStream(input).MinBy(keyFn)
+		assert.NoError(err)
+		assert.Equal("a", min)
+
+		max, err := newStream(input).MaxBy(keyFn)
+		assert.NoError(err)
+		assert.Equal("ccc", max)
+	})
+
+	t.Run("stream numeric, stats", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stats, err := newStream([]T{2, 4, 4, 4, 5, 5, 7, 9}).Stats()
+		assert.NoError(err)
+		assert.Equal(8, stats.Count)
+		assert.InDelta(40, stats.Sum, 1e-9)
+		assert.InDelta(2, stats.Min, 1e-9)
+		assert.InDelta(9, stats.Max, 1e-9)
+		assert.InDelta(5, stats.Mean, 1e-9)
+		assert.InDelta(4, stats.Variance, 1e-9)
+		assert.InDelta(2, stats.StdDev, 1e-9)
+	})
+
+	t.Run("stream numeric, empty stream", func(t *testing.T) {
+		assert := assert.New(t)
+
+		sum, err := newStream(nil).Sum()
+		assert.NoError(err)
+		assert.Equal(0.0, sum)
+
+		_, err = newStream(nil).Min()
+		assert.Equal(streamer.ErrEmptyStream, err)
+
+		_, err = newStream(nil).Average()
+		assert.Equal(streamer.ErrEmptyStream, err)
+
+		_, err = newStream(nil).Stats()
+		assert.Equal(streamer.ErrEmptyStream, err)
+	})
+
+	t.Run("stream numeric, integers are summed exactly", func(t *testing.T) {
+		assert := assert.New(t)
+
+		sum, err := newStream([]T{int64(1 << 62), int64(1), int64(-1 << 62)}).Sum()
+		assert.NoError(err)
+		assert.Equal(1.0, sum)
+
+		sum, err = newStream([]T{uint64(1 << 63), uint64(1 << 63)}).Sum()
+		assert.NoError(err)
+		assert.Equal(float64(1<<63)*2, sum)
+
+		sum, err = newStream([]T{int64(1 << 62), 1, int64(-1 << 62), 0.5}).Sum()
+		assert.NoError(err)
+		assert.Equal(1.5, sum)
+	})
+
+	t.Run("stream numeric, non-numeric element", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := newStream([]T{1, "2", 3}).Sum()
+		nonNumeric, ok := err.(*streamer.NonNumericError)
+		assert.True(ok)
+		assert.Equal("2", nonNumeric.Item)
+
+		_, err = newStream([]T{1, nil}).Max()
+		assert.Error(err)
+
+		_, err = newStream([]T{1, 2}).Stats()
+		assert.NoError(err)
+	})
+}
+
+func Test_stream_cycle(t *testing.T) {
+	type (
+		expectation struct {
+			input          []T
+			expectedOutput []T
+			passes         int
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{
+				nil,
+				nil,
+				3,
+			},
+			{
+				[]T{1, 2, 3},
+				nil,
+				0,
+			},
+			{
+				[]T{1, 2, 3},
+				[]T{1, 2, 3},
+				1,
+			},
+			{
+				[]T{1, 2},
+				[]T{1, 2, 1, 2, 1, 2},
+				3,
+			},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			input          = exp.input
+			expectedOutput = exp.expectedOutput
+			passes         = exp.passes
+		)
+
+		t.Run(fmt.Sprintf("stream cycle n, slice iterator, test case %v", i+1), func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+
+				iterator streamer.Iterator = streamer.NewSliceIterator(input)
+				stream                     = streamer.NewStream(iterator)
+			)
+
+			stream = stream.CycleN(passes)
+
+			assert.Equal(expectedOutput, collect(stream))
+		})
+
+		t.Run(fmt.Sprintf("stream cycle n, buffered, test case %v", i+1), func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+
+				iterator streamer.Iterator = streamer.NewSliceIterator(input)
+				stream                     = streamer.NewStream(iterator)
+			)
+
+			stream = stream.
+				Map(func(x T) T { return x }).
+				CycleN(passes)
+
+			assert.Equal(expectedOutput, collect(stream))
+		})
+	}
+
+	t.Run("stream cycle forever", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			endpoints = []T{"a", "b", "c"}
+			stream    = streamer.NewStream(streamer.NewSliceIterator(endpoints))
+		)
+
+		stream = stream.Filter(func(x T) bool { return true }).Cycle().Take(7)
+
+		assert.Equal([]T{"a", "b", "c", "a", "b", "c", "a"}, collect(stream))
+	})
+
+	t.Run("stream cycle, partially consumed slice iterator", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			iterator = streamer.NewSliceIterator([]T{1, 2, 3})
+		)
+
+		iterator.Next()
+		stream := streamer.NewStream(iterator).Cycle().Take(4)
+
+		assert.Equal([]T{2, 3, 2, 3}, collect(stream))
+	})
+
+	t.Run("stream cycle, empty stream ends", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(nil)).Map(func(x T) T { return x }).Cycle()
+
+		assert.Nil(collect(stream))
+	})
+}
+
+func Test_stream_enumerate(t *testing.T) {
+	t.Run("stream enumerate keeps source positions", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input          = []T{"a", "b", "c", "d"}
+			expectedOutput = []T{
+				streamer.Pair{Key: 1, Value: "b"},
+				streamer.Pair{Key: 3, Value: "d"},
+			}
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			Enumerate().
+			Filter(func(x T) bool { return x.(streamer.Pair).Key.(int)%2 == 1 })
+
+		assert.Equal(expectedOutput, collect(stream))
+	})
+
+	t.Run("stream enumerate empty stream", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Nil(collect(streamer.NewStream(streamer.NewSliceIterator(nil)).Enumerate()))
+	})
+
+	t.Run("stream map indexed", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []T{10, 20, 30}
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			MapIndexed(func(index int, x T) T { return index * x.(int) })
+
+		assert.Equal([]T{0, 20, 60}, collect(stream))
+	})
+
+	t.Run("stream filter indexed counts positions after earlier stages", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = []T{1, 2, 3, 4, 5, 6}
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			SkipWhile(func(x T) bool { return x.(int) < 3 }).
+			FilterIndexed(func(index int, x T) bool { return index%2 == 0 })
+
+		assert.Equal([]T{3, 5}, collect(stream))
+	})
+
+	t.Run("stream indexed operators see source positions carried by enumerate", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = []T{1, 2, 3, 4, 5, 6}
+			value  = func(x T) int { return x.(streamer.Pair).Value.(int) }
+			source = func() *streamer.Stream {
+				return streamer.NewStream(streamer.NewSliceIterator(input)).
+					Enumerate().
+					SkipWhile(func(x T) bool { return value(x) < 3 })
+			}
+		)
+
+		stream := source().
+			Filter(func(x T) bool { return value(x) != 4 }).
+			MapIndexed(func(index int, x T) T { return fmt.Sprintf("%d:%v", index, x) })
+
+		assert.Equal([]T{"2:3", "4:5", "5:6"}, collect(stream))
+
+		stream = source().
+			FilterIndexed(func(index int, x T) bool { return index%2 == 1 }).
+			MapIndexed(func(index int, x T) T { return index * x.(int) })
+
+		assert.Equal([]T{12, 30}, collect(stream))
+	})
+}
+
+func Test_stream_tap(t *testing.T) {
+	t.Run("stream tap sees every element", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input  = []T{1, 2, 3, 4}
+			tapped []T
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+			Tap(func(x T) { tapped = append(tapped, x) }).
+			Filter(func(x T) bool { return x.(int)%2 == 0 })
+
+		assert.Equal([]T{2, 4}, collect(stream))
+		assert.Equal(input, tapped)
+	})
+
+	t.Run("stream log emits a record per element", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			buf    bytes.Buffer
+			logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{"a", "b"})).Log(logger, "parsed")
+
+		assert.Equal([]T{"a", "b"}, collect(stream))
+		assert.Equal("Slice -> Log", stream.Describe())
+		assert.Equal(
+			"level=INFO msg=parsed index=0 element=a\n"+
+				"level=INFO msg=parsed index=1 element=b\n",
+			buf.String())
+	})
+
+	t.Run("stream log every n elements", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			buf    bytes.Buffer
+			logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		)
+
+		stream := streamer.Range(1, 8, 1).LogEvery(logger, "progress", 3)
+
+		assert.Len(collect(stream), 7)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(lines, 2)
+		assert.Contains(lines[0], `"count":3,"element":3`)
+		assert.Contains(lines[1], `"count":6,"element":6`)
+	})
+}
+
+func Test_stream_map_with_retry(t *testing.T) {
+	var (
+		errFlaky     = errors.New("flaky")
+		errPermanent = errors.New("permanent")
+	)
+
+	// failNTimes returns a map function that fails n times for each element
+	failNTimes := func(n int, err error) (func(T) (T, error), map[T]int) {
+		calls := make(map[T]int)
+		return func(x T) (T, error) {
+			calls[x]++
+			if calls[x] <= n {
+				return nil, err
+			}
+			return x.(int) * 10, nil
+		}, calls
+	}
+
+	t.Run("stream map with retry, succeeds after retries", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			mapFn, calls = failNTimes(2, errFlaky)
+			policy       = streamer.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 1500 * time.Millisecond}
+			clock        = streamertest.NewAutoAdvancingClock()
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1, 2})).
+			WithClock(clock).
+			MapWithRetry(mapFn, policy)
+
+		assert.Equal([]T{10, 20}, collect(stream))
+		assert.NoError(stream.Err())
+		assert.Equal(map[T]int{1: 3, 2: 3}, calls)
+
+		second, maxBackoff := time.Second, 1500*time.Millisecond
+		assert.Equal([]time.Duration{second, maxBackoff, second, maxBackoff}, clock.Waits())
+	})
+
+	t.Run("stream map with retry, jitter shortens backoffs", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			mapFn, _ = failNTimes(4, errFlaky)
+			policy   = streamer.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, Multiplier: 1, Jitter: 0.5}
+			clock    = streamertest.NewAutoAdvancingClock()
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1})).
+			WithClock(clock).
+			MapWithRetry(mapFn, policy)
+
+		assert.Equal([]T{10}, collect(stream))
+
+		waits := clock.Waits()
+		assert.Len(waits, 4)
+		for _, wait := range waits {
+			assert.True(wait > 500*time.Millisecond && wait <= time.Second)
+		}
+	})
+
+	t.Run("stream map with retry, gives up after max attempts", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			mapFn, calls = failNTimes(5, errFlaky)
+			policy       = streamer.RetryPolicy{MaxAttempts: 3}
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1, 2})).
+			WithClock(streamertest.NewAutoAdvancingClock()).
+			MapWithRetry(mapFn, policy)
+
+		assert.Empty(collect(stream))
+		assert.True(errors.Is(stream.Err(), errFlaky))
+		assert.Equal(map[T]int{1: 3}, calls)
+	})
+
+	t.Run("stream map with retry, does not retry permanent errors", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			mapFn, calls = failNTimes(1, errPermanent)
+			policy       = streamer.RetryPolicy{
+				Retryable: func(err error) bool { return err != errPermanent },
+			}
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1})).
+			WithClock(streamertest.NewAutoAdvancingClock()).
+			MapWithRetry(mapFn, policy)
+
+		assert.Empty(collect(stream))
+		assert.True(errors.Is(stream.Err(), errPermanent))
+		assert.Equal(1, calls[1])
+	})
+
+	t.Run("stream map with retry, honours context cancellation", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			ctx, cancel  = context.WithCancel(context.Background())
+			mapFn, calls = failNTimes(1, errFlaky)
+			policy       = streamer.RetryPolicy{Context: ctx, InitialBackoff: time.Hour}
+			clock        = streamertest.NewFakeClock()
+		)
+
+		cancel()
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1})).
+			WithClock(clock).
+			MapWithRetry(mapFn, policy)
+
+		assert.Empty(collect(stream))
+		assert.Equal(context.Canceled, stream.Err())
+		assert.Equal(1, calls[1])
+		// the backoff timer is stopped, not left to fire
+		assert.Zero(clock.Pending())
+	})
+}
+
+// arrivingAt returns a stream whose elements arrive after the given gaps.
+func arrivingAt(clock *streamertest.FakeClock, input []T, gaps []time.Duration) *streamer.Stream {
+	return streamer.NewStream(streamer.NewSliceIterator(input)).
+		MapIndexed(func(index int, x T) T {
+			clock.Advance(gaps[index])
+			return x
+		}).
+		WithClock(clock)
+}
+
+func Test_stream_rate_limit(t *testing.T) {
+	t.Run("stream rate limit delays elements beyond the burst", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock = streamertest.NewAutoAdvancingClock()
+		)
+
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1, 2, 3, 4, 5})).
+			WithClock(clock).
+			RateLimit(2, time.Second)
+
+		assert.Equal([]T{1, 2, 3, 4, 5}, collect(stream))
+		assert.Equal([]time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}, clock.Waits())
+	})
+
+	t.Run("stream rate limit refills while elements are slow", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock = streamertest.NewAutoAdvancingClock()
+			gaps  = []time.Duration{0, 0, 500 * time.Millisecond, 250 * time.Millisecond}
+		)
+
+		stream := arrivingAt(clock, []T{1, 2, 3, 4}, gaps).RateLimit(2, time.Second)
+
+		assert.Equal([]T{1, 2, 3, 4}, collect(stream))
+		assert.Equal([]time.Duration{250 * time.Millisecond}, clock.Waits())
+	})
+
+	t.Run("stream rate limit clamps its arguments", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := streamertest.NewAutoAdvancingClock()
+		stream := streamer.NewStream(streamer.NewSliceIterator([]T{1, 2, 3})).
+			WithClock(clock).
+			RateLimit(0, time.Second)
+
+		assert.Equal("Slice -> RateLimit(1, 1s)", stream.Describe())
+		assert.Equal([]T{1, 2, 3}, collect(stream))
+		assert.Equal([]time.Duration{time.Second, time.Second}, clock.Waits())
+
+		clock = streamertest.NewAutoAdvancingClock()
+		stream = streamer.NewStream(streamer.NewSliceIterator([]T{1, 2, 3})).
+			WithClock(clock).
+			RateLimit(1, 0)
+
+		assert.Equal([]T{1, 2, 3}, collect(stream))
+		assert.Empty(clock.Waits())
+	})
+
+	t.Run("stream throttle drops elements within the window", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock = streamertest.NewAutoAdvancingClock()
+			ms    = time.Millisecond
+			gaps  = []time.Duration{0, 300 * ms, 300 * ms, 500 * ms, 100 * ms, 1100 * ms}
+		)
+
+		stream := arrivingAt(clock, []T{1, 2, 3, 4, 5, 6}, gaps).Throttle(time.Second)
+
+		assert.Equal([]T{1, 4, 6}, collect(stream))
+		assert.Empty(clock.Waits())
+	})
+
+	t.Run("stream debounce keeps the last element of each burst", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock = streamertest.NewAutoAdvancingClock()
+			ms    = time.Millisecond
+			gaps  = []time.Duration{0, 100 * ms, 100 * ms, 800 * ms, 100 * ms, 900 * ms}
+		)
+
+		stream := arrivingAt(clock, []T{"a", "b", "c", "d", "e", "f"}, gaps).Debounce(500 * ms)
+
+		assert.Equal([]T{"c", "e", "f"}, collect(stream))
+	})
+}
+
+// blockingIterator yields its elements, then blocks until released.
+type blockingIterator struct {
+	items   []T
+	release chan struct{}
+
+	// released is set once a blocked Next returns; closedBlocked if Close
+	// was called before that
+	released      bool
+	closedBlocked bool
+
+	// closed, if set, is closed by Close
+	closed chan struct{}
+	// err is reported by Err once a blocked Next returns
+	err error
+}
+
+func (bi *blockingIterator) Next() (interface{}, bool) {
+	if len(bi.items) == 0 {
+		<-bi.release
+		bi.released = true
+		return nil, false
+	}
+	item := bi.items[0]
+	bi.items = bi.items[1:]
+	return item, true
+}
+
+func (bi *blockingIterator) Err() error {
+	if bi.released {
+		return bi.err
+	}
+	return nil
+}
+
+func (bi *blockingIterator) Close() error {
+	bi.closedBlocked = !bi.released
+	if bi.closed != nil {
+		close(bi.closed)
+	}
+	return nil
+}
+
+func Test_stream_timeout(t *testing.T) {
+	type (
+		expectation struct {
+			mode        streamer.TimeoutMode
+			expectedErr error
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{streamer.TimeoutFail, streamer.ErrTimeout},
+			{streamer.TimeoutEnd, nil},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			mode        = exp.mode
+			expectedErr = exp.expectedErr
+		)
+
+		t.Run(fmt.Sprintf("stream timeout, test case %v", i+1), func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+
+				clock  = streamertest.NewFakeClock()
+				source = &blockingIterator{items: []T{1, 2}, release: make(chan struct{})}
+			)
+			defer close(source.release)
+
+			stream := streamer.NewStream(source).
+				WithClock(clock).
+				Timeout(time.Second, mode)
+
+			go func() {
+				// the timers of the first two elements are stopped
+				eventually(func() bool { return len(clock.Waits()) == 3 })
+				clock.Advance(time.Second)
+			}()
+
+			assert.Equal([]T{1, 2}, collect(stream))
+			assert.Equal(expectedErr, stream.Err())
+
+			_, ok := stream.Next()
+			assert.False(ok)
+		})
+	}
+
+	t.Run("stream timeout passes the end of the input", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.Range(0, 3, 1).
+			WithClock(streamertest.NewFakeClock()).
+			Timeout(time.Second, streamer.TimeoutFail)
+
+		assert.Equal([]T{0, 1, 2}, collect(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("stream timeout stops the timer of each element", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock = streamertest.NewFakeClock()
+		)
+
+		stream := streamer.Range(0, 100, 1).
+			WithClock(clock).
+			Timeout(time.Hour, streamer.TimeoutFail)
+
+		assert.Len(collect(stream), 100)
+		assert.NoError(stream.Err())
+		assert.Len(clock.Waits(), 101)
+		assert.Zero(clock.Pending())
+	})
+
+	t.Run("stream timeout close does not wait for a blocked input", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock  = streamertest.NewFakeClock()
+			source = &blockingIterator{release: make(chan struct{}), closed: make(chan struct{})}
+			closed = make(chan error)
+		)
+
+		stream := streamer.NewStream(source).
+			WithClock(clock).
+			Timeout(time.Second, streamer.TimeoutFail)
+
+		go func() {
+			clock.BlockUntil(1)
+			clock.Advance(time.Second)
+		}()
+
+		assert.Empty(collect(stream))
+		assert.Equal(streamer.ErrTimeout, stream.Err())
+
+		go func() { closed <- stream.Close() }()
+		select {
+		case err := <-closed:
+			assert.NoError(err)
+		case <-time.After(time.Second):
+			t.Fatal("Close waits for the blocked input")
+		}
+
+		// the worker closes the input once its Next call returns
+		close(source.release)
+		select {
+		case <-source.closed:
+			assert.False(source.closedBlocked)
+		case <-time.After(time.Second):
+			t.Fatal("the input is not closed")
+		}
+	})
+
+	t.Run("stream timeout reports errors of the input once its pending call returns", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			clock  = streamertest.NewFakeClock()
+			errIn  = errors.New("input failed")
+			source = &blockingIterator{release: make(chan struct{}), closed: make(chan struct{}), err: errIn}
+		)
+
+		stream := streamer.NewStream(source).
+			WithClock(clock).
+			Timeout(time.Second, streamer.TimeoutEnd)
+
+		go func() {
+			clock.BlockUntil(1)
+			clock.Advance(time.Second)
+		}()
+
+		assert.Empty(collect(stream))
+
+		// Err does not look into the input while its Next call is running
+		go close(source.release)
+		assert.NoError(stream.Err())
+
+		assert.NoError(stream.Close())
+		<-source.closed
+		assert.Equal(errIn, stream.Err())
+	})
+
+	t.Run("stream timeout passes panics of the input on", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(&panickingIterator{}).
+			WithClock(streamertest.NewFakeClock()).
+			OnPanic(streamer.PanicAsError).
+			Timeout(time.Second, streamer.TimeoutFail)
+
+		assert.Empty(collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("broken source", panicErr.Value)
+	})
+}
+
+func Test_stream_buffer(t *testing.T) {
+	type (
+		expectation struct {
+			size int
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{0},
+			{1},
+			{3},
+			{50},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			size = exp.size
+		)
+
+		t.Run(fmt.Sprintf("stream buffer, test case %v", i+1), func(t *testing.T) {
+			assert := assert.New(t)
+
+			stream := streamer.Range(0, 20, 1).Buffer(size)
+
+			assert.Equal(collect(streamer.Range(0, 20, 1)), collect(stream))
+			assert.NoError(stream.Err())
+
+			_, ok := stream.Next()
+			assert.False(ok)
+		})
+	}
+
+	t.Run("stream buffer pulls ahead of the consumer", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			pulled int64
+		)
+
+		stream := streamer.Generate(func() interface{} { return atomic.AddInt64(&pulled, 1) }).
+			Buffer(3)
+		defer stream.Close()
+
+		item, ok := stream.Next()
+		assert.True(ok)
+		assert.Equal(int64(1), item)
+
+		// one element handed out, three ready, and one waiting for room
+		assert.True(eventually(func() bool { return atomic.LoadInt64(&pulled) == 5 }))
+	})
+
+	t.Run("stream buffer stops pulling when closed", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			pulled int64
+		)
+
+		stream := streamer.Generate(func() interface{} { return atomic.AddInt64(&pulled, 1) }).
+			Buffer(2)
+
+		_, ok := stream.Next()
+		assert.True(ok)
+		assert.NoError(stream.Close())
+
+		stopped := atomic.LoadInt64(&pulled)
+		_, ok = stream.Next()
+		assert.False(ok)
+		assert.Equal(stopped, atomic.LoadInt64(&pulled))
+	})
+
+	t.Run("stream buffer close does not wait for a blocked input", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			source = &blockingIterator{items: []T{1}, release: make(chan struct{}), closed: make(chan struct{})}
+			closed = make(chan error)
+		)
+
+		stream := streamer.NewStream(source).Buffer(0)
+
+		item, ok := stream.Next()
+		assert.True(ok)
+		assert.Equal(1, item)
+
+		go func() { closed <- stream.Close() }()
+		select {
+		case err := <-closed:
+			assert.NoError(err)
+		case <-time.After(time.Second):
+			t.Fatal("Close waits for the blocked input")
+		}
+
+		// the goroutine closes the input once its Next call returns
+		close(source.release)
+		select {
+		case <-source.closed:
+			assert.False(source.closedBlocked)
+		case <-time.After(time.Second):
+			t.Fatal("the input is not closed")
+		}
+	})
+
+	t.Run("stream buffer stops pulling when abandoned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		before := runtime.NumGoroutine()
+		func() {
+			stream := streamer.Generate(func() interface{} { return 1 }).Buffer(2)
+			_, ok := stream.Next()
+			assert.True(ok)
+		}()
+
+		assert.True(eventually(func() bool {
+			runtime.GC()
+			return runtime.NumGoroutine() <= before
+		}))
+	})
+
+	t.Run("stream buffer passes errors of the input on", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.JSONLines(strings.NewReader("1\n{\n2\n"), nil).Buffer(2)
+
+		assert.Equal([]T{1.0}, collect(stream))
+
+		var lineErr *streamer.JSONLineError
+		assert.True(errors.As(stream.Err(), &lineErr))
+		assert.Equal(2, lineErr.Line)
+	})
+
+	t.Run("stream buffer passes panics of the input on", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.NewStream(&panickingIterator{}).
+			OnPanic(streamer.PanicAsError).
+			Buffer(2)
+
+		assert.Empty(collect(stream))
+
+		panicErr, ok := stream.Err().(*streamer.PanicError)
+		assert.True(ok)
+		assert.Equal("*streamer_test.panickingIterator", panicErr.Stage)
+		assert.Equal("broken source", panicErr.Value)
+	})
+}
+
+// eventually polls cond for up to a second.
+func eventually(cond func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return cond()
+}
+
+func Test_stream_window_by_time(t *testing.T) {
+	type (
+		expectation struct {
+			input          []T
+			expectedOutput []T
+			size, slide    time.Duration
+			lateness       time.Duration
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{
+				nil,
+				nil,
+				10 * time.Second, 10 * time.Second,
+				0,
+			},
+			{
+				[]T{1, 3, 12, 15, 27},
+				[]T{
+					[]T{1, 3},
+					[]T{12, 15},
+					[]T{27},
+				},
+				10 * time.Second, 10 * time.Second,
+				0,
+			},
+			{
+				[]T{1, 3, 12, 15, 27},
+				[]T{
+					[]T{1, 3},
+					[]T{12, 15},
+					[]T{27},
+				},
+				10 * time.Second, 0,
+				0,
+			},
+			{
+				[]T{1, 6, 12},
+				[]T{
+					[]T{1},
+					[]T{1, 6},
+					[]T{6, 12},
+					[]T{12},
+				},
+				10 * time.Second, 5 * time.Second,
+				0,
+			},
+			{
+				[]T{1, 7, 12},
+				[]T{
+					[]T{1},
+					[]T{12},
+				},
+				5 * time.Second, 10 * time.Second,
+				0,
+			},
+			{
+				[]T{1, 12, 5, 15},
+				[]T{
+					[]T{1},
+					[]T{12, 15},
+				},
+				10 * time.Second, 10 * time.Second,
+				0,
+			},
+			{
+				[]T{1, 12, 5, 16, 3},
+				[]T{
+					[]T{1, 5},
+					[]T{12, 16},
+				},
+				10 * time.Second, 10 * time.Second,
+				5 * time.Second,
+			},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			input          = exp.input
+			expectedOutput = exp.expectedOutput
+			size           = exp.size
+			slide          = exp.slide
+			lateness       = exp.lateness
+		)
+
+		t.Run(fmt.Sprintf("stream window by time, test case %v", i+1), func(t *testing.T) {
+			assert := assert.New(t)
+
+			stream := streamer.NewStream(streamer.NewSliceIterator(input)).
+				WindowByTime(size, slide, unixSeconds, streamer.WindowOptions{AllowedLateness: lateness})
+
+			assert.Equal(expectedOutput, collect(stream))
+		})
+	}
+
+	t.Run("stream window by time rejects a size <= 0", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.Range(0, 3, 1).
+			WindowByTime(0, 0, unixSeconds, streamer.WindowOptions{})
+
+		assert.Empty(collect(stream))
+		assert.Equal(streamer.ErrWindowSize, stream.Err())
+	})
+
+	t.Run("stream window by time emits windows as the watermark passes them", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input = make(chan interface{}, 2)
+		)
+
+		stream := streamer.NewStream(streamer.NewChannelIterator(input, -1)).
+			WindowByTime(10*time.Second, 10*time.Second, unixSeconds, streamer.WindowOptions{})
+
+		input <- 1
+		input <- 12
+
+		item, ok := stream.Next()
+		assert.True(ok)
+		assert.Equal([]T{1}, item)
+
+		close(input)
+		assert.Equal([]T{[]T{12}}, collect(stream))
+	})
+}
+
+func unixSeconds(x interface{}) time.Time { return time.Unix(int64(x.(int)), 0) }
@@ -0,0 +1,145 @@
+// Package streamertest provides helpers for testing streamer pipelines.
+package streamertest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dc0d/streamer"
+)
+
+// FakeClock is a streamer.Clock whose time only moves when it is advanced.
+// An auto-advancing FakeClock instead jumps forward to the deadline of every
+// After call, so waiting stages never block.
+type FakeClock struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	now         time.Time
+	autoAdvance bool
+	waiters     []*waiter
+	waits       []time.Duration
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock creates a FakeClock that moves only when advanced.
+func NewFakeClock() *FakeClock {
+	return newFakeClock(false)
+}
+
+// NewAutoAdvancingClock creates a FakeClock that advances on every After call.
+func NewAutoAdvancingClock() *FakeClock {
+	return newFakeClock(true)
+}
+
+func newFakeClock(autoAdvance bool) *FakeClock {
+	res := &FakeClock{
+		now:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		autoAdvance: autoAdvance,
+	}
+	res.cond = sync.NewCond(&res.mu)
+	return res
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *FakeClock) After(d time.Duration) <-chan time.Time {
+	return fc.wait(d).ch
+}
+
+// NewTimer returns a timer that fires like an After channel, and can be
+// stopped.
+func (fc *FakeClock) NewTimer(d time.Duration) streamer.Timer {
+	return &fakeTimer{clock: fc, waiter: fc.wait(d)}
+}
+
+func (fc *FakeClock) wait(d time.Duration) *waiter {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.waits = append(fc.waits, d)
+	w := &waiter{deadline: fc.now.Add(d), ch: make(chan time.Time, 1)}
+	if fc.autoAdvance {
+		fc.now = w.deadline
+	}
+	fc.waiters = append(fc.waiters, w)
+	fc.fire()
+	fc.cond.Broadcast()
+	return w
+}
+
+// Advance moves the clock forward, firing the After channels that are due.
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.now = fc.now.Add(d)
+	fc.fire()
+}
+
+// BlockUntil waits until n After channels or timers are pending.
+func (fc *FakeClock) BlockUntil(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for len(fc.waiters) < n {
+		fc.cond.Wait()
+	}
+}
+
+// Waits returns the durations of all After and NewTimer calls so far.
+func (fc *FakeClock) Waits() []time.Duration {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return append([]time.Duration(nil), fc.waits...)
+}
+
+func (fc *FakeClock) fire() {
+	pending := fc.waiters[:0]
+	for _, w := range fc.waiters {
+		if w.deadline.After(fc.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- fc.now
+	}
+	fc.waiters = pending
+}
+
+// stop removes w from the pending waiters, and reports whether it was pending.
+func (fc *FakeClock) stop(w *waiter) bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for i, pending := range fc.waiters {
+		if pending == w {
+			fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the number of After channels and timers that have not fired
+// nor been stopped.
+func (fc *FakeClock) Pending() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return len(fc.waiters)
+}
+
+type fakeTimer struct {
+	clock  *FakeClock
+	waiter *waiter
+}
+
+func (ft *fakeTimer) C() <-chan time.Time { return ft.waiter.ch }
+
+func (ft *fakeTimer) Stop() bool { return ft.clock.stop(ft.waiter) }
@@ -0,0 +1,192 @@
+package streamer
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+const defaultTDigestCompression = 100
+
+// TDigest is a mergeable sketch for estimating quantiles of a stream of
+// numbers in bounded memory (a merging t-digest). Higher compression means
+// more centroids and more accurate estimates.
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// NewTDigest creates a t-digest. A compression <= 0 defaults to 100.
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = defaultTDigestCompression
+	}
+	res := &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+	return res
+}
+
+func (td *TDigest) Add(x float64) { td.add(centroid{mean: x, weight: 1}) }
+
+func (td *TDigest) Count() int { return int(td.count) }
+
+// Merge adds all values summarized by other into td.
+func (td *TDigest) Merge(other *TDigest) {
+	for _, c := range other.centroids {
+		td.add(c)
+	}
+	for _, c := range other.buffer {
+		td.add(c)
+	}
+	td.min = math.Min(td.min, other.min)
+	td.max = math.Max(td.max, other.max)
+}
+
+// Quantile returns the estimated value at quantile q in [0, 1], or NaN if
+// the digest is empty.
+func (td *TDigest) Quantile(q float64) float64 {
+	td.compress()
+
+	if len(td.centroids) == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return td.min
+	}
+	if q >= 1 {
+		return td.max
+	}
+
+	first, last := td.centroids[0], td.centroids[len(td.centroids)-1]
+	if len(td.centroids) == 1 {
+		return first.mean
+	}
+
+	index := q * td.count
+	if index < first.weight/2 {
+		return td.min + (first.mean-td.min)*index/(first.weight/2)
+	}
+
+	cumulative := first.weight / 2
+	for i := 0; i < len(td.centroids)-1; i++ {
+		left, right := td.centroids[i], td.centroids[i+1]
+		gap := (left.weight + right.weight) / 2
+		if index < cumulative+gap {
+			return left.mean + (right.mean-left.mean)*(index-cumulative)/gap
+		}
+		cumulative += gap
+	}
+
+	tail := td.count - last.weight/2
+	return last.mean + (td.max-last.mean)*(index-tail)/(last.weight/2)
+}
+
+func (td *TDigest) add(c centroid) {
+	td.buffer = append(td.buffer, c)
+	td.count += c.weight
+	td.min = math.Min(td.min, c.mean)
+	td.max = math.Max(td.max, c.mean)
+	if float64(len(td.buffer)) >= 5*td.compression {
+		td.compress()
+	}
+}
+
+func (td *TDigest) compress() {
+	if len(td.buffer) == 0 {
+		return
+	}
+
+	all := append(td.centroids, td.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	var (
+		merged  = []centroid{all[0]}
+		soFar   float64
+		qLimit  = td.qLimit(0)
+		current = &merged[0]
+	)
+
+	for _, c := range all[1:] {
+		if (soFar+current.weight+c.weight)/td.count <= qLimit {
+			current.weight += c.weight
+			current.mean += (c.mean - current.mean) * c.weight / current.weight
+			continue
+		}
+		soFar += current.weight
+		qLimit = td.qLimit(soFar / td.count)
+		merged = append(merged, c)
+		current = &merged[len(merged)-1]
+	}
+
+	td.centroids = merged
+	td.buffer = nil
+}
+
+// qLimit returns the largest quantile a centroid starting at q may reach,
+// using the k1 scale function k(q) = compression/(2*pi) * asin(2q-1).
+func (td *TDigest) qLimit(q float64) float64 {
+	k := td.compression/(2*math.Pi)*math.Asin(2*q-1) + 1
+	if k >= td.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/td.compression) + 1) / 2
+}
+
+// tdigestEncodingVersion is the first byte of a marshaled TDigest.
+const tdigestEncodingVersion = 1
+
+var errTDigestEncoding = errors.New("streamer: invalid t-digest encoding")
+
+// MarshalBinary encodes the digest, so sketches of shards can be sent
+// elsewhere and merged; it implements encoding.BinaryMarshaler, so gob uses it.
+func (td *TDigest) MarshalBinary() ([]byte, error) {
+	td.compress()
+
+	res := make([]byte, 1, 1+8*(4+2*len(td.centroids)))
+	res[0] = tdigestEncodingVersion
+	for _, v := range []float64{td.compression, td.min, td.max, float64(len(td.centroids))} {
+		res = binary.BigEndian.AppendUint64(res, math.Float64bits(v))
+	}
+	for _, c := range td.centroids {
+		res = binary.BigEndian.AppendUint64(res, math.Float64bits(c.mean))
+		res = binary.BigEndian.AppendUint64(res, math.Float64bits(c.weight))
+	}
+	return res, nil
+}
+
+// UnmarshalBinary decodes a digest encoded by MarshalBinary into td.
+func (td *TDigest) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+8*4 || data[0] != tdigestEncodingVersion {
+		return errTDigestEncoding
+	}
+
+	values := make([]float64, (len(data)-1)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.BigEndian.Uint64(data[1+8*i:]))
+	}
+	n := int(values[3])
+	if (len(data)-1)%8 != 0 || n < 0 || len(values) != 4+2*n {
+		return errTDigestEncoding
+	}
+
+	*td = TDigest{compression: values[0], min: values[1], max: values[2]}
+	for i := 0; i < n; i++ {
+		c := centroid{mean: values[4+2*i], weight: values[5+2*i]}
+		td.centroids = append(td.centroids, c)
+		td.count += c.weight
+	}
+	return nil
+}
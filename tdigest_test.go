@@ -0,0 +1,98 @@
+package streamer_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func Test_tdigest(t *testing.T) {
+	t.Run("estimates quantiles of a uniform stream", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			input []interface{}
+		)
+
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 100000; i++ {
+			input = append(input, rnd.Float64()*1000)
+		}
+
+		digest, err := streamer.NewStream(streamer.NewSliceIterator(input)).QuantileSketch(100)
+		assert.NoError(err)
+		assert.Equal(len(input), digest.Count())
+
+		for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+			assert.InDelta(q*1000, digest.Quantile(q), 10)
+		}
+	})
+
+	t.Run("merges sketches of shards", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			shard1 = streamer.NewTDigest(100)
+			shard2 = streamer.NewTDigest(100)
+		)
+
+		for i := 1; i <= 5000; i++ {
+			shard1.Add(float64(i))
+			shard2.Add(float64(i + 5000))
+		}
+
+		shard1.Merge(shard2)
+
+		assert.Equal(10000, shard1.Count())
+		assert.Equal(1.0, shard1.Quantile(0))
+		assert.Equal(10000.0, shard1.Quantile(1))
+		assert.InDelta(5000, shard1.Quantile(0.5), 50)
+		assert.InDelta(9900, shard1.Quantile(0.99), 20)
+	})
+
+	t.Run("merges sketches sent through gob", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			shard1 = streamer.NewTDigest(100)
+			shard2 = streamer.NewTDigest(100)
+			buf    bytes.Buffer
+		)
+
+		for i := 1; i <= 5000; i++ {
+			shard1.Add(float64(i))
+			shard2.Add(float64(i + 5000))
+		}
+
+		assert.NoError(gob.NewEncoder(&buf).Encode(shard2))
+
+		received := streamer.NewTDigest(0)
+		assert.NoError(gob.NewDecoder(&buf).Decode(received))
+		assert.Equal(5000, received.Count())
+
+		shard1.Merge(received)
+
+		assert.Equal(10000, shard1.Count())
+		assert.Equal(1.0, shard1.Quantile(0))
+		assert.Equal(10000.0, shard1.Quantile(1))
+		assert.InDelta(5000, shard1.Quantile(0.5), 50)
+
+		assert.Error(received.UnmarshalBinary([]byte{1, 2, 3}))
+	})
+
+	t.Run("empty and single value sketches", func(t *testing.T) {
+		assert := assert.New(t)
+
+		digest := streamer.NewTDigest(0)
+		assert.True(math.IsNaN(digest.Quantile(0.5)))
+
+		digest.Add(42)
+		assert.Equal(42.0, digest.Quantile(0.5))
+	})
+}
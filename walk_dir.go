@@ -0,0 +1,164 @@
+package streamer
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// WalkOptions configures WalkDir.
+type WalkOptions struct {
+	// FS is the file system to walk, with root being a path in it. Defaults
+	// to the operating system's file system.
+	FS fs.FS
+	// Include, if not empty, restricts the entries yielded to those whose base
+	// name or path, as reported in WalkEntry.Path, matches one of the globs
+	// (see path.Match, or filepath.Match on the operating system's file
+	// system). Directories are still walked.
+	Include []string
+	// Exclude removes the entries whose base name or path matches one of the
+	// globs, as for Include. Excluded directories are not walked.
+	Exclude []string
+	// MaxDepth limits how deep the walk goes; the entries of root are at
+	// depth 1. Zero means no limit.
+	MaxDepth int
+	// SkipErrors skips directories that can not be read, instead of ending
+	// the stream with the error.
+	SkipErrors bool
+}
+
+// WalkEntry is an element of the stream returned by WalkDir.
+type WalkEntry struct {
+	Path  string
+	Entry fs.DirEntry
+}
+
+type walkDirIterator struct {
+	root    string
+	fsys    fs.FS
+	options WalkOptions
+
+	// paths in fsys use slashes; on the os file system they are reported as
+	// native paths joined to root
+	native  bool
+	started bool
+	stack   []walkFrame
+	err     error
+}
+
+type walkFrame struct {
+	dir     string
+	depth   int
+	entries []fs.DirEntry
+}
+
+// WalkDir returns a lazy stream of the WalkEntry values under root, in
+// lexical order, depth first. Root itself is not included. Each directory is
+// read only when the walk reaches it.
+func WalkDir(root string, options WalkOptions) *Stream {
+	iterator := &walkDirIterator{
+		root:    root,
+		fsys:    options.FS,
+		options: options,
+	}
+	if iterator.fsys == nil {
+		iterator.fsys = os.DirFS(root)
+		iterator.native = true
+	}
+	return NewStream(iterator)
+}
+
+func (wd *walkDirIterator) Next() (interface{}, bool) {
+	if wd.err != nil {
+		return nil, false
+	}
+
+	if !wd.started {
+		wd.started = true
+		start := wd.root
+		if wd.native {
+			start = "."
+		}
+		if !wd.push(start, 0) {
+			return nil, false
+		}
+	}
+
+	for len(wd.stack) > 0 {
+		top := &wd.stack[len(wd.stack)-1]
+		if len(top.entries) == 0 {
+			wd.stack = wd.stack[:len(wd.stack)-1]
+			continue
+		}
+
+		entry := top.entries[0]
+		top.entries = top.entries[1:]
+
+		entryPath := path.Join(top.dir, entry.Name())
+		depth := top.depth + 1
+
+		if wd.matches(wd.options.Exclude, entryPath) {
+			continue
+		}
+
+		if entry.IsDir() && (wd.options.MaxDepth == 0 || depth < wd.options.MaxDepth) {
+			if !wd.push(entryPath, depth) {
+				return nil, false
+			}
+		}
+
+		if len(wd.options.Include) > 0 && !wd.matches(wd.options.Include, entryPath) {
+			continue
+		}
+
+		return WalkEntry{Path: wd.reportedPath(entryPath), Entry: entry}, true
+	}
+
+	return nil, false
+}
+
+func (wd *walkDirIterator) Err() error { return wd.err }
+
+func (wd *walkDirIterator) push(dir string, depth int) bool {
+	entries, err := fs.ReadDir(wd.fsys, dir)
+	if err != nil {
+		if wd.options.SkipErrors {
+			return true
+		}
+		wd.err = err
+		return false
+	}
+	wd.stack = append(wd.stack, walkFrame{dir: dir, depth: depth, entries: entries})
+	return true
+}
+
+// matches reports whether the base name or the reported path of entryPath
+// matches one of globs.
+func (wd *walkDirIterator) matches(globs []string, entryPath string) bool {
+	var (
+		reported = wd.reportedPath(entryPath)
+		match    = path.Match
+		base     = path.Base(reported)
+	)
+	if wd.native {
+		match, base = filepath.Match, filepath.Base(reported)
+	}
+
+	for _, glob := range globs {
+		if ok, _ := match(glob, base); ok {
+			return true
+		}
+		if ok, _ := match(glob, reported); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (wd *walkDirIterator) reportedPath(entryPath string) string {
+	if wd.native {
+		return filepath.Join(wd.root, filepath.FromSlash(entryPath))
+	}
+	return entryPath
+}
@@ -0,0 +1,134 @@
+package streamer_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dc0d/streamer"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func walkPaths(stream *streamer.Stream) []interface{} {
+	return collect(stream.Map(func(x interface{}) interface{} { return x.(streamer.WalkEntry).Path }))
+}
+
+func Test_walk_dir(t *testing.T) {
+	var (
+		fsys = fstest.MapFS{
+			"root/a.txt":             {},
+			"root/b.log":             {},
+			"root/sub/c.txt":         {},
+			"root/sub/deep/d.txt":    {},
+			"root/vendor/e.txt":      {},
+			"root/sub/deep/skip.tmp": {},
+		}
+	)
+
+	type (
+		expectation struct {
+			options        streamer.WalkOptions
+			expectedOutput []interface{}
+		}
+	)
+
+	var (
+		expectations = []expectation{
+			{
+				streamer.WalkOptions{},
+				[]interface{}{
+					"root/a.txt", "root/b.log", "root/sub", "root/sub/c.txt", "root/sub/deep",
+					"root/sub/deep/d.txt", "root/sub/deep/skip.tmp", "root/vendor", "root/vendor/e.txt",
+				},
+			},
+			{
+				streamer.WalkOptions{Include: []string{"*.txt"}, Exclude: []string{"vendor", "*.tmp"}},
+				[]interface{}{"root/a.txt", "root/sub/c.txt", "root/sub/deep/d.txt"},
+			},
+			{
+				streamer.WalkOptions{MaxDepth: 1},
+				[]interface{}{"root/a.txt", "root/b.log", "root/sub", "root/vendor"},
+			},
+			{
+				streamer.WalkOptions{MaxDepth: 2, Include: []string{"root/sub/*"}},
+				[]interface{}{"root/sub/c.txt", "root/sub/deep"},
+			},
+		}
+	)
+
+	for i, exp := range expectations {
+		var (
+			options        = exp.options
+			expectedOutput = exp.expectedOutput
+		)
+
+		t.Run(fmt.Sprintf("walk dir, test case %v", i+1), func(t *testing.T) {
+			assert := assert.New(t)
+
+			options.FS = fsys
+			stream := streamer.WalkDir("root", options)
+
+			assert.Equal(expectedOutput, walkPaths(stream))
+			assert.NoError(stream.Err())
+		})
+	}
+
+	t.Run("walk dir, missing root fails", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.WalkDir("missing", streamer.WalkOptions{FS: fsys})
+
+		assert.Empty(walkPaths(stream))
+		assert.Error(stream.Err())
+	})
+
+	t.Run("walk dir, missing root skipped", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stream := streamer.WalkDir("missing", streamer.WalkOptions{FS: fsys, SkipErrors: true})
+
+		assert.Empty(walkPaths(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("walk dir, os file system", func(t *testing.T) {
+		assert := assert.New(t)
+
+		root, err := ioutil.TempDir("", "streamer-test")
+		assert.NoError(err)
+		defer os.RemoveAll(root)
+
+		assert.NoError(os.MkdirAll(filepath.Join(root, "x", "y"), 0755))
+		assert.NoError(ioutil.WriteFile(filepath.Join(root, "x", "y", "z.txt"), nil, 0644))
+
+		stream := streamer.WalkDir(root, streamer.WalkOptions{}).
+			Filter(func(x interface{}) bool { return !x.(streamer.WalkEntry).Entry.IsDir() })
+
+		assert.Equal([]interface{}{filepath.Join(root, "x", "y", "z.txt")}, walkPaths(stream))
+		assert.NoError(stream.Err())
+	})
+
+	t.Run("walk dir, os file system globs match the reported paths", func(t *testing.T) {
+		assert := assert.New(t)
+
+		root, err := ioutil.TempDir("", "streamer-test")
+		assert.NoError(err)
+		defer os.RemoveAll(root)
+
+		assert.NoError(os.MkdirAll(filepath.Join(root, "x", "y"), 0755))
+		assert.NoError(ioutil.WriteFile(filepath.Join(root, "x", "a.txt"), nil, 0644))
+		assert.NoError(ioutil.WriteFile(filepath.Join(root, "x", "y", "z.txt"), nil, 0644))
+
+		stream := streamer.WalkDir(root, streamer.WalkOptions{
+			Include: []string{filepath.Join(root, "x", "*")},
+			Exclude: []string{filepath.Join(root, "x", "y")},
+		})
+
+		assert.Equal([]interface{}{filepath.Join(root, "x", "a.txt")}, walkPaths(stream))
+		assert.NoError(stream.Err())
+	})
+}